@@ -0,0 +1,91 @@
+// Package cache remembers which checks passed with which inputs, so that
+// they can be skipped while their inputs stay the same.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/alfranz/hush/internal/watch"
+)
+
+// Store keeps, per check, the key it last passed with in a file under Dir.
+type Store struct {
+	Dir string
+	// Root is the directory input globs are relative to.
+	Root string
+}
+
+// Key hashes settings, such as the command and its environment, together
+// with the path and content of every file below Root that matches one of
+// the input globs. Files ignored by .gitignore are skipped.
+func (s *Store) Key(inputs []string, settings ...string) (string, error) {
+	h := sha256.New()
+	for _, setting := range settings {
+		fmt.Fprintf(h, "%d:%s\n", len(setting), setting)
+	}
+	err := watch.Files(s.Root, func(rel string) error {
+		for _, glob := range inputs {
+			if watch.Match(glob, rel) {
+				return hashFile(h, s.Root, rel)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(h io.Writer, root, rel string) error {
+	f, err := os.Open(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(h, "%d:%s\n%d\n", len(rel), rel, info.Size())
+	_, err = io.Copy(h, f)
+	return err
+}
+
+// Passed reports whether the check name last passed with key.
+func (s *Store) Passed(name, key string) bool {
+	data, err := os.ReadFile(s.path(name))
+	return err == nil && string(data) == key
+}
+
+// Record notes that the check name passed with key.
+func (s *Store) Record(name, key string) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(name), []byte(key), 0o644)
+}
+
+// Forget drops what is recorded for the check name.
+func (s *Store) Forget(name string) error {
+	if err := os.Remove(s.path(name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Clear drops everything recorded.
+func (s *Store) Clear() error {
+	return os.RemoveAll(s.Dir)
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.Dir, url.PathEscape(name))
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	root := t.TempDir()
+	os.Mkdir(filepath.Join(root, "src"), 0755)
+	os.WriteFile(filepath.Join(root, "src", "app.py"), []byte("print(1)\n"), 0644)
+	os.WriteFile(filepath.Join(root, "README.md"), []byte("readme\n"), 0644)
+	s := &Store{Dir: filepath.Join(root, ".hush", "cache"), Root: root}
+
+	key := func(settings ...string) string {
+		t.Helper()
+		k, err := s.Key([]string{"*.py"}, settings...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+
+	base := key("ruff check .")
+	if key("ruff check .") != base {
+		t.Error("expected the same key for unchanged inputs")
+	}
+	if key("ruff check --fix .") == base {
+		t.Error("expected the command to change the key")
+	}
+	if key("ruff check", " .") == base {
+		t.Error("expected settings to be kept apart")
+	}
+
+	os.WriteFile(filepath.Join(root, "README.md"), []byte("changed\n"), 0644)
+	if key("ruff check .") != base {
+		t.Error("expected files outside the inputs not to change the key")
+	}
+	os.WriteFile(filepath.Join(root, "src", "app.py"), []byte("print(2)\n"), 0644)
+	if key("ruff check .") == base {
+		t.Error("expected an input change to change the key")
+	}
+}
+
+func TestStore(t *testing.T) {
+	s := &Store{Dir: filepath.Join(t.TempDir(), "cache")}
+	if s.Passed("lint", "abc") {
+		t.Error("expected nothing recorded")
+	}
+	if err := s.Record("lint", "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Passed("lint", "abc") || s.Passed("lint", "def") || s.Passed("test", "abc") {
+		t.Error("expected only lint to have passed with abc")
+	}
+	if err := s.Forget("lint"); err != nil {
+		t.Fatal(err)
+	}
+	if s.Passed("lint", "abc") {
+		t.Error("expected lint to be forgotten")
+	}
+
+	s.Record("frontend/lint", "abc")
+	if err := s.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if s.Passed("frontend/lint", "abc") {
+		t.Error("expected the cache to be cleared")
+	}
+}
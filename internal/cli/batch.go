@@ -3,8 +3,10 @@ package cli
 import (
 	"context"
 	"os"
+	"sync"
+	"time"
 
-	"github.com/alfranz/hush/internal/filter"
+	"github.com/alfranz/hush/internal/config"
 	"github.com/alfranz/hush/internal/output"
 	"github.com/alfranz/hush/internal/runner"
 	"github.com/spf13/cobra"
@@ -13,13 +15,46 @@ import (
 var batchFlags struct {
 	sharedFlags
 	continueOnError bool
+	parallel        int
+}
+
+// job is a single command in a batch together with its resolved flags.
+type job struct {
+	command string
+	flags   sharedFlags
+	// name is the check name for jobs from .hush.yaml.
+	name string
+	// needs holds indices of jobs that must pass before this one runs.
+	needs []int
+	// inputs are the check's input globs; a job without any is never cached.
+	inputs []string
+	// skip is the reason the job is not run at all, if it is not.
+	skip string
+}
+
+// label returns the label shown for the job before it has run.
+func (j job) label() string {
+	if j.flags.label != "" {
+		return j.flags.label
+	}
+	if j.name != "" {
+		return j.name
+	}
+	return runner.DeriveLabel(j.command)
+}
+
+type batchOptions struct {
+	continueOnError bool
+	parallel        int
+	// output carries the --format and --junit settings.
+	output sharedFlags
 }
 
 func newBatchCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "batch [flags] <command1> <command2> ...",
-		Short: "Run multiple commands sequentially",
-		Long:  "Runs commands sequentially and shows a summary.\nStops on first failure by default; use --continue to run all.",
+		Short: "Run multiple commands",
+		Long:  "Runs commands and shows a summary.\nStops on first failure by default; use --continue to run all.\nUse --parallel N to run up to N commands at once; results are still printed in order.",
 		Args:  cobra.MinimumNArgs(1),
 		RunE:  runBatch,
 		// Don't show errors twice
@@ -29,63 +64,298 @@ func newBatchCmd() *cobra.Command {
 
 	addSharedFlags(cmd, &batchFlags.sharedFlags)
 	cmd.Flags().BoolVar(&batchFlags.continueOnError, "continue", false, "Continue running after a failure")
+	addParallelFlags(cmd, &batchFlags.parallel)
 	return cmd
 }
 
+// addParallelFlags registers --parallel and its --jobs alias.
+func addParallelFlags(cmd *cobra.Command, parallel *int) {
+	cmd.Flags().IntVarP(parallel, "parallel", "j", 1, "Run up to N commands concurrently")
+	cmd.Flags().IntVar(parallel, "jobs", 1, "Alias for --parallel")
+}
+
+// resolveParallel returns the parallelism from flags, falling back to defaults.parallel.
+func resolveParallel(cmd *cobra.Command, parallel int, cfg *config.Config) int {
+	if !cmd.Flags().Changed("parallel") && !cmd.Flags().Changed("jobs") && cfg != nil && cfg.Defaults.Parallel > 0 {
+		parallel = cfg.Defaults.Parallel
+	}
+	return parallel
+}
+
 func runBatch(cmd *cobra.Command, args []string) error {
 	cfg := loadConfigQuiet()
 	f := applyDefaults(cmd, batchFlags.sharedFlags, cfg)
+	// A single label makes no sense across several commands
+	f.label = ""
+	if err := f.validate(); err != nil {
+		return err
+	}
+	openRuns(cfg)
 
 	continueOnError := batchFlags.continueOnError
 	if !cmd.Flags().Changed("continue") && cfg != nil && cfg.Defaults.Continue {
 		continueOnError = true
 	}
 
-	return executeBatch(args, f, continueOnError)
+	jobs := make([]job, len(args))
+	for i, command := range args {
+		jobs[i] = job{command: command, flags: withToolDefaults(f, command)}
+	}
+	return executeBatch(jobs, batchOptions{
+		continueOnError: continueOnError,
+		parallel:        resolveParallel(cmd, batchFlags.parallel, cfg),
+		output:          f,
+	})
 }
 
-func executeBatch(commands []string, f sharedFlags, continueOnError bool) error {
-	passed := 0
-	total := len(commands)
-	firstFailCode := 0
+func executeBatch(jobs []job, opts batchOptions) error {
+	out, err := newFormatter(opts.output)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+	summary, err := runJobsWithSummary(ctx, out, jobs, opts)
+	if err != nil {
+		// Still write the --junit report for the jobs that did run
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
 
-	for _, command := range commands {
-		result, err := runner.Run(context.Background(), runner.Options{
-			Command: command,
-		})
-		if err != nil {
-			return err
+	if ctx.Err() != nil {
+		os.Exit(interruptedExitCode)
+	}
+	if summary.firstFailCode != 0 {
+		os.Exit(summary.firstFailCode)
+	}
+	return nil
+}
+
+// runJobsWithSummary runs jobs like runJobs and prints the batch summary.
+func runJobsWithSummary(ctx context.Context, out output.Formatter, jobs []job, opts batchOptions) (batchSummary, error) {
+	start := time.Now()
+	summary, err := runJobs(ctx, out, jobs, opts)
+	if err != nil {
+		return summary, err
+	}
+
+	// Jobs skipped up front don't count towards the total
+	total := 0
+	for _, j := range jobs {
+		if j.skip == "" {
+			total++
 		}
+	}
+	// The summary counts as complete if all passed or we ran all commands
+	out.Summary(output.Summary{
+		Passed:   summary.passed,
+		Total:    total,
+		Stopped:  summary.passed != total && !opts.continueOnError,
+		Duration: time.Since(start),
+	})
+	return summary, nil
+}
+
+type batchSummary struct {
+	passed        int
+	firstFailCode int
+}
 
-		filtered := filter.Apply(result.Output, filter.Options{
-			Head:      f.head,
-			Tail:      f.tail,
-			Grep:      f.grep,
-			StripANSI: true,
-		})
-		warnings := buildWarningReport(result.Output, f)
-
-		output.PrintResult(os.Stdout, result.Label, result.ExitCode, filtered, warnings.count, warnings.lines)
-
-		if result.ExitCode == 0 {
-			passed++
-		} else {
-			if firstFailCode == 0 {
-				firstFailCode = result.ExitCode
+// jobOutcome is the result of a single job. A job that never started, or
+// was cancelled because a sibling failed, is skipped; skipReason is set when
+// it was cancelled while running or skipped because a job it needs did not
+// pass. A cached job did not run because it already passed with the same
+// inputs.
+type jobOutcome struct {
+	result     *runner.Result
+	err        error
+	skipped    bool
+	skipReason string
+	cached     bool
+	// cacheKey identifies the job's inputs, if it has any.
+	cacheKey string
+	done     chan struct{}
+}
+
+func (o *jobOutcome) passed() bool {
+	return o.cached || o.err == nil && !o.skipped && o.result.ExitCode == 0
+}
+
+// runJobs runs jobs with up to opts.parallel at a time and prints each
+// result in declaration order as soon as it and all earlier jobs are done.
+// Jobs wait for the jobs they need and are skipped if one of them did not
+// pass. Unless opts.continueOnError is set, the first failure cancels the rest.
+func runJobs(ctx context.Context, out output.Formatter, jobs []job, opts batchOptions) (batchSummary, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	outcomes := make([]*jobOutcome, len(jobs))
+	for i := range outcomes {
+		outcomes[i] = &jobOutcome{done: make(chan struct{})}
+	}
+
+	var wg sync.WaitGroup
+	go schedule(ctx, cancel, &wg, jobs, outcomes, opts)
+
+	var summary batchSummary
+	var firstErr error
+	for i, o := range outcomes {
+		<-o.done
+		if o.err != nil {
+			if firstErr == nil {
+				firstErr = o.err
 			}
-			if !continueOnError {
-				break
+			continue
+		}
+		if firstErr != nil || o.skipped {
+			if o.result != nil {
+				o.result.Close()
+			}
+		}
+		if firstErr != nil {
+			continue
+		}
+		if o.skipped {
+			if o.skipReason != "" {
+				out.Result(skippedReport(jobs[i], o.skipReason))
 			}
+			continue
+		}
+
+		if o.cached {
+			out.Result(cachedReport(jobs[i]))
+			summary.passed++
+			continue
+		}
+
+		r := printReport(out, o.result, jobs[i].flags)
+		updateCache(jobs[i], o.cacheKey, r.Status)
+
+		if o.result.ExitCode == 0 {
+			summary.passed++
+		} else if summary.firstFailCode == 0 {
+			summary.firstFailCode = o.result.ExitCode
 		}
 	}
+	wg.Wait()
+
+	return summary, firstErr
+}
+
+// schedule starts jobs in index order as their dependencies finish and
+// slots free up, so a parallelism of 1 runs them strictly in order.
+func schedule(ctx context.Context, cancel context.CancelFunc, wg *sync.WaitGroup, jobs []job, outcomes []*jobOutcome, opts batchOptions) {
+	const (
+		pending = iota
+		running
+		finished
+	)
+	parallel := max(opts.parallel, 1)
+	state := make([]int, len(jobs))
+	finishedCh := make(chan int)
+	active, remaining := 0, len(jobs)
 
-	// Print summary if all passed or we ran all commands
-	if passed == total || continueOnError {
-		output.PrintBatchSummary(os.Stdout, passed, total)
+	finish := func(i int) {
+		state[i] = finished
+		remaining--
+		close(outcomes[i].done)
 	}
 
-	if firstFailCode != 0 {
-		os.Exit(firstFailCode)
+	for remaining > 0 {
+		for progress := true; progress; {
+			progress = false
+			for i, j := range jobs {
+				if state[i] != pending {
+					continue
+				}
+				ready, failedDep := true, -1
+				for _, dep := range j.needs {
+					if state[dep] != finished {
+						ready = false
+						break
+					}
+					if failedDep < 0 && !outcomes[dep].passed() {
+						failedDep = dep
+					}
+				}
+				if !ready {
+					continue
+				}
+
+				out := outcomes[i]
+				switch {
+				case j.skip != "":
+					out.skipped = true
+					out.skipReason = j.skip
+				case failedDep >= 0:
+					out.skipped = true
+					out.skipReason = "skipped: " + dependencyName(jobs[failedDep], outcomes[failedDep]) + " failed"
+				case ctx.Err() != nil:
+					out.skipped = true
+				case active < parallel:
+					state[i] = running
+					active++
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						if out.cacheKey = cacheKey(j); out.cacheKey != "" && !j.flags.noCache && checkCache.Passed(j.name, out.cacheKey) {
+							out.cached = true
+							finishedCh <- i
+							return
+						}
+						// Concurrent jobs can't share a rolling tail
+						out.result, out.err = runCommand(ctx, j.command, j.flags, parallel == 1 || len(jobs) == 1)
+						switch {
+						case out.err != nil:
+							cancel()
+						case out.result.ExitCode == 0:
+						case ctx.Err() != nil:
+							// Killed because a sibling failed first
+							out.skipped = true
+							out.skipReason = "cancelled"
+						case !opts.continueOnError:
+							cancel()
+						}
+						finishedCh <- i
+					}()
+					continue
+				default:
+					continue
+				}
+				finish(i)
+				progress = true
+			}
+		}
+
+		if active == 0 {
+			// Unreachable for an acyclic graph, but never leave the
+			// printer waiting on a job that cannot start.
+			for i := range jobs {
+				if state[i] == pending {
+					outcomes[i].skipped = true
+					finish(i)
+				}
+			}
+			break
+		}
+		i := <-finishedCh
+		active--
+		finish(i)
 	}
-	return nil
+}
+
+// dependencyName returns the name a skipped job's needs refer to: the check
+// name, or for an unnamed batch job the label it was reported under.
+func dependencyName(j job, o *jobOutcome) string {
+	if j.name != "" {
+		return j.name
+	}
+	if o.result != nil {
+		return o.result.Label
+	}
+	return j.label()
 }
@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alfranz/hush/internal/cache"
+	"github.com/alfranz/hush/internal/output"
+)
+
+func textOut(buf *bytes.Buffer) output.Formatter {
+	out, _ := output.NewFormatter(buf, "text", output.FormatOptions{})
+	return out
+}
+
+func jobsFor(commands ...string) []job {
+	jobs := make([]job, len(commands))
+	for i, c := range commands {
+		jobs[i] = job{command: c}
+	}
+	return jobs
+}
+
+func TestRunJobsSequential(t *testing.T) {
+	var buf bytes.Buffer
+	summary, err := runJobs(t.Context(), textOut(&buf), jobsFor("echo one", "exit 3", "echo three"), batchOptions{parallel: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.passed != 1 || summary.firstFailCode != 3 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if strings.Contains(buf.String(), "three") {
+		t.Errorf("expected batch to stop at first failure, got: %q", buf.String())
+	}
+}
+
+func TestRunJobsParallelKeepsOrder(t *testing.T) {
+	var buf bytes.Buffer
+	jobs := jobsFor("sleep 0.3; echo a", "sleep 0.1; echo b", "echo c")
+	jobs[0].flags.label = "first"
+	jobs[1].flags.label = "second"
+	jobs[2].flags.label = "third"
+
+	start := time.Now()
+	summary, err := runJobs(t.Context(), textOut(&buf), jobs, batchOptions{parallel: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected concurrent execution, took %s", elapsed)
+	}
+	if summary.passed != 3 {
+		t.Errorf("expected 3 passed, got %d", summary.passed)
+	}
+	if got := buf.String(); got != "✓ first\n✓ second\n✓ third\n" {
+		t.Errorf("expected declared order, got: %q", got)
+	}
+}
+
+func TestRunJobsParallelFailFastCancelsSiblings(t *testing.T) {
+	var buf bytes.Buffer
+	start := time.Now()
+	summary, err := runJobs(t.Context(), textOut(&buf), jobsFor("sleep 10", "exit 2", "sleep 10"), batchOptions{parallel: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected siblings to be cancelled, took %s", elapsed)
+	}
+	if summary.firstFailCode != 2 {
+		t.Errorf("expected first fail code 2, got %d", summary.firstFailCode)
+	}
+	if got := buf.String(); got != "⊘ sleep (cancelled)\n✗ exit\n" {
+		t.Errorf("expected the cancelled and failing commands, got: %q", got)
+	}
+}
+
+func TestRunJobsParallelContinue(t *testing.T) {
+	var buf bytes.Buffer
+	summary, err := runJobs(t.Context(), textOut(&buf), jobsFor("exit 1", "echo ok", "exit 2"), batchOptions{parallel: 3, continueOnError: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.passed != 1 || summary.firstFailCode != 1 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if got := buf.String(); got != "✗ exit\n✓ echo\n✗ exit\n" {
+		t.Errorf("unexpected output: %q", got)
+	}
+}
+
+func TestRunJobsNeedsSkipsDependents(t *testing.T) {
+	var buf bytes.Buffer
+	jobs := []job{
+		{command: "exit 1", name: "build", flags: sharedFlags{label: "build"}},
+		{command: "echo ok", name: "test", needs: []int{0}},
+		{command: "echo ok", name: "lint", flags: sharedFlags{label: "lint"}},
+	}
+	summary, err := runJobs(t.Context(), textOut(&buf), jobs, batchOptions{continueOnError: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.passed != 1 || summary.firstFailCode != 1 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if got := buf.String(); got != "✗ build\n⊘ test (skipped: build failed)\n✓ lint\n" {
+		t.Errorf("unexpected output: %q", got)
+	}
+}
+
+func TestRunJobsNeedsNamesCheck(t *testing.T) {
+	var buf bytes.Buffer
+	jobs := []job{
+		{command: "exit 2", name: "build"},
+		{command: "echo ok", name: "test", needs: []int{0}},
+	}
+	if _, err := runJobs(t.Context(), textOut(&buf), jobs, batchOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := buf.String(); got != "✗ exit\n⊘ test (skipped: build failed)\n" {
+		t.Errorf("unexpected output: %q", got)
+	}
+}
+
+func TestRunJobsNeedsSkipsTransitively(t *testing.T) {
+	var buf bytes.Buffer
+	jobs := []job{
+		{command: "exit 1", name: "build", flags: sharedFlags{label: "build"}},
+		{command: "echo ok", name: "test", needs: []int{0}},
+		{command: "echo ok", name: "deploy", needs: []int{1}},
+	}
+	if _, err := runJobs(t.Context(), textOut(&buf), jobs, batchOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "✗ build\n⊘ test (skipped: build failed)\n⊘ deploy (skipped: test failed)\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output: %q", got)
+	}
+}
+
+func TestRunJobsNeedsWaitsForDependency(t *testing.T) {
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	jobs := []job{
+		{command: "sleep 0.2; touch " + dir + "/built", flags: sharedFlags{label: "build"}},
+		{command: "test -f " + dir + "/built", flags: sharedFlags{label: "test"}, needs: []int{0}},
+		{command: "true", flags: sharedFlags{label: "lint"}},
+	}
+	summary, err := runJobs(t.Context(), textOut(&buf), jobs, batchOptions{parallel: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.passed != 3 {
+		t.Errorf("expected dependent to see build output, got: %q", buf.String())
+	}
+}
+
+func TestRunJobsCache(t *testing.T) {
+	dir := t.TempDir()
+	checkCache = &cache.Store{Dir: filepath.Join(dir, ".hush", "cache"), Root: dir}
+	t.Cleanup(func() { checkCache = nil })
+	os.WriteFile(filepath.Join(dir, "app.py"), []byte("print(1)\n"), 0644)
+
+	run := func(j job) string {
+		t.Helper()
+		var buf bytes.Buffer
+		summary, err := runJobs(t.Context(), textOut(&buf), []job{j}, batchOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if summary.passed != 1 {
+			t.Errorf("expected the check to pass, got: %q", buf.String())
+		}
+		return buf.String()
+	}
+	lint := job{command: "echo run >> " + dir + "/runs.log", name: "lint", inputs: []string{"*.py"}, flags: sharedFlags{label: "lint"}}
+
+	if got := run(lint); got != "✓ lint\n" {
+		t.Errorf("expected the first run to run, got: %q", got)
+	}
+	if got := run(lint); got != "✓ lint (cached)\n" {
+		t.Errorf("expected the second run to be cached, got: %q", got)
+	}
+	noCache := lint
+	noCache.flags.noCache = true
+	if got := run(noCache); got != "✓ lint\n" {
+		t.Errorf("expected --no-cache to run the check, got: %q", got)
+	}
+	os.WriteFile(filepath.Join(dir, "app.py"), []byte("print(2)\n"), 0644)
+	if got := run(lint); got != "✓ lint\n" {
+		t.Errorf("expected an input change to run the check, got: %q", got)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "runs.log"))
+	if n := strings.Count(string(data), "run"); n != 3 {
+		t.Errorf("expected 3 runs, got %d", n)
+	}
+
+	var buf bytes.Buffer
+	failing := job{command: "exit 1", name: "lint", inputs: []string{"*.py"}}
+	runJobs(t.Context(), textOut(&buf), []job{failing}, batchOptions{})
+	if got := run(lint); got != "✓ lint\n" {
+		t.Errorf("expected a failure to clear the cached pass, got: %q", got)
+	}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alfranz/hush/internal/cache"
+	"github.com/alfranz/hush/internal/config"
+	"github.com/alfranz/hush/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// checkCache remembers checks that passed with unchanged inputs; nil
+// disables caching.
+var checkCache *cache.Store
+
+func newCacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cache of checks that passed with unchanged inputs",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:           "clear",
+		Short:         "Forget which checks passed, so that they all run again",
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cacheStore(loadConfigQuiet()).Clear(); err != nil {
+				return err
+			}
+			fmt.Fprintln(os.Stdout, "✓ cache cleared")
+			return nil
+		},
+	})
+	return cmd
+}
+
+// cacheStore returns the check cache next to .hush.yaml, or in the current
+// directory if there is no config file.
+func cacheStore(cfg *config.Config) *cache.Store {
+	dir := "."
+	if cfg != nil {
+		dir = cfg.Dir
+	}
+	return &cache.Store{Dir: filepath.Join(dir, ".hush", "cache"), Root: dir}
+}
+
+// cacheKey hashes the job's inputs together with the settings that change
+// what it runs. It returns "" if the job cannot be cached.
+func cacheKey(j job) string {
+	if checkCache == nil || len(j.inputs) == 0 {
+		return ""
+	}
+	settings := []string{j.command, j.flags.shell, j.flags.dir, strings.Join(j.flags.defaultEnv, "\n"), strings.Join(j.flags.env, "\n")}
+	if j.flags.envFile != "" {
+		data, err := os.ReadFile(j.flags.envFile)
+		if err != nil {
+			return ""
+		}
+		settings = append(settings, string(data))
+	}
+	key, err := checkCache.Key(j.inputs, settings...)
+	if err != nil {
+		return ""
+	}
+	return key
+}
+
+// updateCache records a job that passed cleanly under key, and forgets one
+// that did not so that it runs next time. Like recording runs for hush
+// last, this is best effort.
+func updateCache(j job, key string, status output.Status) {
+	if key == "" {
+		return
+	}
+	if status == output.StatusPass {
+		checkCache.Record(j.name, key)
+	} else {
+		checkCache.Forget(j.name)
+	}
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/alfranz/hush/internal/config"
+)
+
+// changedFiles lists the files in dir that differ from HEAD, or from where
+// the current branch forked off since if it is set, including untracked
+// files. Paths are relative to dir; files outside it are left out.
+func changedFiles(dir, since string) ([]string, error) {
+	diff := []string{"diff", "-z", "--name-only", "--relative", "HEAD"}
+	if since != "" {
+		diff = []string{"diff", "-z", "--name-only", "--relative", "--merge-base", since}
+	}
+	changed, err := git(dir, diff...)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git(dir, "ls-files", "-z", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	return append(changed, untracked...), nil
+}
+
+// git runs a git command in dir and returns its NUL-separated output.
+func git(dir string, args ...string) ([]string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// The first line says what went wrong; the rest is advice
+		if msg, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n"); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	var paths []string
+	for _, p := range strings.Split(string(out), "\x00") {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
+// changedChecks returns the checks among names that are relevant to a
+// change to files, relative to the config file: those without paths globs,
+// those with a glob matching one of files, the checks that need them and
+// the checks they need.
+func changedChecks(cfg *config.Config, names, files []string) []string {
+	return cfg.WithNeeds(withDependents(cfg, names, func(check config.Check) bool {
+		return len(check.Paths) == 0 || matchesAny(check.Paths, files)
+	})...)
+}
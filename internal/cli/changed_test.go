@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/alfranz/hush/internal/config"
+)
+
+func TestChangedChecks(t *testing.T) {
+	cfg, err := config.Parse([]byte(`checks:
+  frontend:
+    cmd: npm test
+    paths: ["web/**"]
+  api:
+    cmd: go test ./...
+    paths: ["services/api/**", "go.mod"]
+  build:
+    cmd: make
+    paths: ["src/**"]
+  test:
+    cmd: make test
+    paths: ["tests/**"]
+    needs: [build]
+  spell:
+    cmd: typos
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := cfg.CheckNames()
+
+	tests := []struct {
+		files []string
+		want  []string
+	}{
+		{nil, []string{"spell"}},
+		{[]string{"web/app.ts"}, []string{"frontend", "spell"}},
+		{[]string{"go.mod", "web/index.html"}, []string{"frontend", "api", "spell"}},
+		{[]string{"src/main.c"}, []string{"build", "test", "spell"}},
+		{[]string{"tests/test_main.c"}, []string{"build", "test", "spell"}},
+	}
+	for _, tt := range tests {
+		if got := changedChecks(cfg, names, tt.files); !slices.Equal(got, tt.want) {
+			t.Errorf("changedChecks(%q) = %q, want %q", tt.files, got, tt.want)
+		}
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=hush", "-c", "user.email=hush@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %q: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(repo, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "-q", "-b", "main")
+	write("app/main.go", "package main")
+	write("app/web/index.html", "<html>")
+	write("docs/guide.md", "# Guide")
+	run("add", ".")
+	run("commit", "-q", "-m", "initial")
+	run("checkout", "-q", "-b", "feature")
+	write("app/main.go", "package main // changed")
+	run("commit", "-q", "-am", "change main")
+	write("app/web/index.html", "<html>edited")
+	write("app/new file.txt", "new")
+	write("docs/guide.md", "# Guide, edited")
+
+	dir := filepath.Join(repo, "app")
+	got, err := changedFiles(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"web/index.html", "new file.txt"}; !slices.Equal(got, want) {
+		t.Errorf("changedFiles = %q, want %q", got, want)
+	}
+
+	got, err = changedFiles(dir, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"main.go", "web/index.html", "new file.txt"}; !slices.Equal(got, want) {
+		t.Errorf("changedFiles since main = %q, want %q", got, want)
+	}
+
+	if _, err := changedFiles(dir, "no-such-ref"); err == nil {
+		t.Error("expected an error for an unknown ref")
+	}
+}
@@ -1,6 +1,17 @@
 package cli
 
-import "github.com/spf13/cobra"
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alfranz/hush/internal/filter"
+	"github.com/alfranz/hush/internal/runner"
+
+	"github.com/spf13/cobra"
+)
 
 type sharedFlags struct {
 	label       string
@@ -9,6 +20,32 @@ type sharedFlags struct {
 	grep        string
 	warnPattern string
 	warnTail    int
+	timeout     time.Duration
+	format      string
+	junit       string
+	durations   bool
+	slow        time.Duration
+	parser      string
+	maxTokens   int
+	grepContext int
+	grepBefore  int
+	grepAfter   int
+	stream      string
+	live        bool
+	maxCapture  string
+	retries     int
+	retryDelay  time.Duration
+	retryOn     string
+	// shell comes from config, or is none for commands given after --.
+	shell string
+	dir   string
+	env   []string
+	// defaultEnv holds defaults.env from the config. The env file is read
+	// on top of it, and env on top of both.
+	defaultEnv []string
+	// envFile is a dotenv file read before env is applied.
+	envFile string
+	noCache bool
 }
 
 func addSharedFlags(cmd *cobra.Command, f *sharedFlags) {
@@ -16,6 +53,120 @@ func addSharedFlags(cmd *cobra.Command, f *sharedFlags) {
 	cmd.PersistentFlags().IntVar(&f.tail, "tail", 0, "Show only last N lines of output on failure")
 	cmd.PersistentFlags().IntVar(&f.head, "head", 0, "Show only first N lines of output on failure")
 	cmd.PersistentFlags().StringVar(&f.grep, "grep", "", "Filter output to lines matching this regex")
+	cmd.PersistentFlags().IntVarP(&f.grepContext, "grep-context", "C", 0, "Show N lines of context around each --grep match")
+	cmd.PersistentFlags().IntVarP(&f.grepBefore, "grep-before", "B", 0, "Show N lines before each --grep match")
+	cmd.PersistentFlags().IntVarP(&f.grepAfter, "grep-after", "A", 0, "Show N lines after each --grep match")
+	cmd.PersistentFlags().IntVar(&f.maxTokens, "max-tokens", 0, "Trim failure output to about N tokens, keeping head and tail")
+	cmd.PersistentFlags().StringVar(&f.parser, "parser", "", "Extract failures with a tool-aware parser: "+strings.Join(filter.ParserNames(), ", "))
+	cmd.PersistentFlags().StringVar(&f.stream, "stream", "", "Output stream to show on failure: stdout, stderr or both (default both)")
 	cmd.PersistentFlags().StringVar(&f.warnPattern, "warn-pattern", "", "On success, treat matching output lines as warnings")
 	cmd.PersistentFlags().IntVar(&f.warnTail, "warn-tail", 0, "On warning-qualified success, show last N warning lines (default 10)")
+	cmd.PersistentFlags().StringVar(&f.format, "format", "text", "Output format: text, json or ndjson")
+	cmd.PersistentFlags().StringVar(&f.junit, "junit", "", "Also write a JUnit XML report to this path")
+	cmd.PersistentFlags().DurationVar(&f.timeout, "timeout", 0, "Kill the command after this duration (e.g. 30s, 5m)")
+	cmd.PersistentFlags().IntVar(&f.retries, "retries", 0, "Rerun a failing command up to N more times")
+	cmd.PersistentFlags().DurationVar(&f.retryDelay, "retry-delay", 0, "Wait this long before each retry (e.g. 2s)")
+	cmd.PersistentFlags().StringVar(&f.retryOn, "retry-on", "", "Only retry failures whose output matches this regex")
+	cmd.PersistentFlags().StringVar(&f.dir, "cwd", "", "Run the command in this directory")
+	cmd.PersistentFlags().StringArrayVar(&f.env, "env", nil, "Set an environment variable for the command, as KEY=VAL (repeatable)")
+	cmd.PersistentFlags().BoolVar(&f.noCache, "no-cache", false, "Run checks even if their inputs are unchanged since they last passed")
+	cmd.PersistentFlags().StringVar(&f.maxCapture, "max-capture", "", "Keep at most this much of each output stream in memory, e.g. 64MB (default 16MB); the rest spills to disk")
+	cmd.PersistentFlags().BoolVar(&f.live, "live", false, "Show progress on stderr while the command runs")
+	cmd.PersistentFlags().BoolVar(&f.durations, "durations", false, "Show run time on summary lines")
+	cmd.PersistentFlags().DurationVar(&f.slow, "slow", 0, "Report a passing command as a warning if it runs longer than this")
+}
+
+// validate rejects flag values that can only be checked after parsing.
+func (f sharedFlags) validate() error {
+	if f.parser != "" && !filter.IsParser(f.parser) {
+		return fmt.Errorf("unknown parser %q (want %s)", f.parser, strings.Join(filter.ParserNames(), ", "))
+	}
+	switch f.stream {
+	case "", "both", "stdout", "stderr":
+	default:
+		return fmt.Errorf("unknown stream %q (want stdout, stderr or both)", f.stream)
+	}
+	if _, err := parseSize(f.maxCapture); err != nil {
+		return fmt.Errorf("invalid --max-capture: %w", err)
+	}
+	for _, kv := range f.env {
+		if key, _, ok := strings.Cut(kv, "="); !ok || key == "" {
+			return fmt.Errorf("invalid --env %q (want KEY=VAL)", kv)
+		}
+	}
+	if f.retries < 0 {
+		return fmt.Errorf("invalid --retries %d (want 0 or more)", f.retries)
+	}
+	if _, err := regexp.Compile(f.retryOn); err != nil {
+		return fmt.Errorf("invalid --retry-on: %w", err)
+	}
+	return nil
+}
+
+// withToolDefaults fills in the parser and warn pattern suited to the tool
+// command runs, where neither flags nor config chose one.
+func withToolDefaults(f sharedFlags, command string) sharedFlags {
+	tool, ok := runner.DetectTool(command)
+	if !ok {
+		return f
+	}
+	if f.parser == "" {
+		f.parser = tool.Parser
+	}
+	if f.warnPattern == "" {
+		f.warnPattern = tool.WarnPattern
+	}
+	return f
+}
+
+// grepWindow returns the context lines to keep before and after each grep
+// match; -B and -A take precedence over -C.
+func (f sharedFlags) grepWindow() (before, after int) {
+	before, after = f.grepBefore, f.grepAfter
+	if before == 0 {
+		before = f.grepContext
+	}
+	if after == 0 {
+		after = f.grepContext
+	}
+	return before, after
+}
+
+// filterOptions returns the output filtering selected by f.
+func (f sharedFlags) filterOptions() filter.Options {
+	before, after := f.grepWindow()
+	return filter.Options{
+		Head:       f.head,
+		Tail:       f.tail,
+		Grep:       f.grep,
+		GrepBefore: before,
+		GrepAfter:  after,
+		Parser:     f.parser,
+		MaxTokens:  f.maxTokens,
+	}
+}
+
+var sizeUnits = map[string]int{
+	"": 1, "B": 1,
+	"K": 1 << 10, "KB": 1 << 10, "KIB": 1 << 10,
+	"M": 1 << 20, "MB": 1 << 20, "MIB": 1 << 20,
+	"G": 1 << 30, "GB": 1 << 30, "GIB": 1 << 30,
+}
+
+// parseSize parses a byte size such as "512KB" or "64MB". Units are powers
+// of 1024. An empty string is zero.
+func parseSize(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	unit, ok := sizeUnits[strings.ToUpper(strings.TrimSpace(s[i:]))]
+	if err != nil || !ok || n <= 0 {
+		return 0, fmt.Errorf("bad size %q (want e.g. 64MB)", s)
+	}
+	return int(n * float64(unit)), nil
 }
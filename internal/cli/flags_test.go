@@ -0,0 +1,106 @@
+package cli
+
+import "testing"
+
+func TestWithToolDefaults(t *testing.T) {
+	f := withToolDefaults(sharedFlags{}, "uv run pytest -x")
+	if f.parser != "pytest" {
+		t.Errorf("expected pytest parser, got %q", f.parser)
+	}
+	if f.warnPattern == "" {
+		t.Error("expected pytest warn pattern")
+	}
+
+	f = withToolDefaults(sharedFlags{parser: "none", warnPattern: "custom"}, "pytest")
+	if f.parser != "none" || f.warnPattern != "custom" {
+		t.Errorf("expected explicit settings to win, got %+v", f)
+	}
+
+	f = withToolDefaults(sharedFlags{}, "make")
+	if f.parser != "" || f.warnPattern != "" {
+		t.Errorf("expected no defaults for unknown tool, got %+v", f)
+	}
+}
+
+func TestValidateParser(t *testing.T) {
+	if err := (sharedFlags{parser: "gotest"}).validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := (sharedFlags{parser: "maven"}).validate(); err == nil {
+		t.Error("expected error for unknown parser")
+	}
+}
+
+func TestValidateStream(t *testing.T) {
+	for _, stream := range []string{"", "both", "stdout", "stderr"} {
+		if err := (sharedFlags{stream: stream}).validate(); err != nil {
+			t.Errorf("stream %q: unexpected error: %v", stream, err)
+		}
+	}
+	if err := (sharedFlags{stream: "stdin"}).validate(); err == nil {
+		t.Error("expected error for unknown stream")
+	}
+}
+
+func TestValidateRetries(t *testing.T) {
+	if err := (sharedFlags{retries: 2, retryOn: "ECONNRESET|ETIMEDOUT"}).validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := (sharedFlags{retries: -1}).validate(); err == nil {
+		t.Error("expected error for negative retries")
+	}
+	if err := (sharedFlags{retryOn: "("}).validate(); err == nil {
+		t.Error("expected error for invalid --retry-on regex")
+	}
+}
+
+func TestValidateEnv(t *testing.T) {
+	if err := (sharedFlags{env: []string{"A=1", "EMPTY="}}).validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, kv := range []string{"A", "=1"} {
+		if err := (sharedFlags{env: []string{kv}}).validate(); err == nil {
+			t.Errorf("expected error for --env %q", kv)
+		}
+	}
+}
+
+func TestGrepWindow(t *testing.T) {
+	tests := []struct {
+		flags                 sharedFlags
+		wantBefore, wantAfter int
+	}{
+		{sharedFlags{}, 0, 0},
+		{sharedFlags{grepContext: 2}, 2, 2},
+		{sharedFlags{grepContext: 2, grepAfter: 5}, 2, 5},
+		{sharedFlags{grepBefore: 1}, 1, 0},
+	}
+	for _, tt := range tests {
+		before, after := tt.flags.grepWindow()
+		if before != tt.wantBefore || after != tt.wantAfter {
+			t.Errorf("grepWindow(%+v) = %d, %d; want %d, %d", tt.flags, before, after, tt.wantBefore, tt.wantAfter)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int{
+		"":      0,
+		"1024":  1024,
+		"512KB": 512 << 10,
+		"64MB":  64 << 20,
+		"64mb":  64 << 20,
+		"1.5G":  3 << 29,
+		"2GiB":  2 << 30,
+	}
+	for in, want := range tests {
+		if got, err := parseSize(in); err != nil || got != want {
+			t.Errorf("parseSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"MB", "-5MB", "10XB", "lots"} {
+		if _, err := parseSize(in); err == nil {
+			t.Errorf("parseSize(%q): expected error", in)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alfranz/hush/internal/scaffold"
+	"github.com/spf13/cobra"
+)
+
+var initFlags struct {
+	yes   bool
+	force bool
+}
+
+func newInitCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Create a .hush.yaml with checks for the tools this project uses",
+		Long: "Looks for pyproject.toml, package.json scripts, go.mod, Cargo.toml, Makefile targets and\n" +
+			"justfile recipes, and writes a .hush.yaml with a check for each tool found. In a terminal\n" +
+			"each check is offered in turn; --yes adds them all.",
+		Args:          cobra.NoArgs,
+		RunE:          runInit,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	cmd.Flags().BoolVarP(&initFlags.yes, "yes", "y", false, "Add every detected check without asking")
+	cmd.Flags().BoolVar(&initFlags.force, "force", false, "Overwrite an existing .hush.yaml")
+	return cmd
+}
+
+func runInit(cmd *cobra.Command, args []string) error {
+	const path = ".hush.yaml"
+	if _, err := os.Stat(path); err == nil && !initFlags.force {
+		return errors.New(".hush.yaml already exists (use --force to overwrite)")
+	}
+	suggestions, err := scaffold.Detect(".")
+	if err != nil {
+		return err
+	}
+	if len(suggestions) == 0 {
+		return errors.New("found no pyproject.toml, package.json, go.mod, Cargo.toml, Makefile or justfile to derive checks from")
+	}
+	if !initFlags.yes {
+		if !isTerminal(os.Stdin) {
+			return errors.New("stdin is not a terminal; pass --yes to add every detected check")
+		}
+		if suggestions = promptChecks(os.Stdin, os.Stdout, suggestions); len(suggestions) == 0 {
+			return errors.New("no checks selected; nothing written (pass --yes to add them all without asking)")
+		}
+	}
+
+	data, err := scaffold.Render(suggestions)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+	noun := "checks"
+	if len(suggestions) == 1 {
+		noun = "check"
+	}
+	fmt.Fprintf(os.Stdout, "✓ wrote .hush.yaml (%d %s); run them with hush all\n", len(suggestions), noun)
+	return nil
+}
+
+// promptChecks asks on w whether to add each suggestion, reading answers
+// from r, and returns those accepted. An empty answer accepts; once r is
+// exhausted the remaining suggestions are declined.
+func promptChecks(r io.Reader, w io.Writer, suggestions []scaffold.Suggestion) []scaffold.Suggestion {
+	in := bufio.NewReader(r)
+	var accepted []scaffold.Suggestion
+	for _, s := range suggestions {
+		fmt.Fprintf(w, "Add %s: %s (from %s)? [Y/n] ", s.Name, s.Check.Cmd, s.Source)
+		answer, err := in.ReadString('\n')
+		if err != nil && answer == "" {
+			fmt.Fprintln(w)
+			break
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "", "y", "yes":
+			accepted = append(accepted, s)
+		}
+	}
+	return accepted
+}
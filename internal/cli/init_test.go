@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alfranz/hush/internal/config"
+	"github.com/alfranz/hush/internal/scaffold"
+)
+
+func TestPromptChecks(t *testing.T) {
+	suggestions := []scaffold.Suggestion{
+		{Name: "lint", Check: config.Check{Cmd: "ruff check ."}, Source: "pyproject.toml"},
+		{Name: "typecheck", Check: config.Check{Cmd: "mypy ."}, Source: "pyproject.toml"},
+		{Name: "test", Check: config.Check{Cmd: "pytest"}, Source: "pyproject.toml"},
+		{Name: "build", Check: config.Check{Cmd: "make build"}, Source: "Makefile"},
+	}
+	var out strings.Builder
+	got := promptChecks(strings.NewReader("\nn\nYes\n"), &out, suggestions)
+
+	var names []string
+	for _, s := range got {
+		names = append(names, s.Name)
+	}
+	if strings.Join(names, " ") != "lint test" {
+		t.Errorf("expected lint and test accepted, got %q", names)
+	}
+	if !strings.HasPrefix(out.String(), "Add lint: ruff check . (from pyproject.toml)? [Y/n] ") {
+		t.Errorf("unexpected prompt: %q", out.String())
+	}
+}
@@ -0,0 +1,288 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alfranz/hush/internal/config"
+	"github.com/alfranz/hush/internal/filter"
+	"github.com/alfranz/hush/internal/history"
+	"github.com/alfranz/hush/internal/output"
+	"github.com/alfranz/hush/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+// runs records each result for hush last; nil disables recording.
+var runs *history.Store
+
+var lastFlags struct {
+	full  bool
+	lines string
+}
+
+func newLastCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "last [label]",
+		Short: "Show the output of the most recent run without rerunning it",
+		Long: "Re-filters the recorded output of the most recent run, or the most recent run with the given label.\n" +
+			"Without flags the output is filtered as it was originally; --grep, --head, --tail and --lines\n" +
+			"filter the raw output instead, and --full prints all of it.",
+		Args:          cobra.MaximumNArgs(1),
+		RunE:          runLast,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	cmd.Flags().BoolVar(&lastFlags.full, "full", false, "Print the complete output")
+	cmd.Flags().StringVar(&lastFlags.lines, "lines", "", "Print only this range of lines, e.g. 100-200")
+	return cmd
+}
+
+func runLast(cmd *cobra.Command, args []string) error {
+	var label string
+	if len(args) > 0 {
+		label = args[0]
+	}
+	lines, err := parseLineRange(lastFlags.lines)
+	if err != nil {
+		return err
+	}
+	if err := flags.validate(); err != nil {
+		return err
+	}
+	return printLast(os.Stdout, runStore(loadConfigQuiet()), label, lastFlags.full, lines, flags)
+}
+
+// printLast writes the output of the most recent run in store, or the most
+// recent run with label, to w. It is filtered as it originally was, unless
+// full is set or lines or the filters in f select something else.
+func printLast(w io.Writer, store *history.Store, label string, full bool, lines filter.Range, f sharedFlags) error {
+	if store == nil {
+		return history.ErrNoRuns
+	}
+	run, err := store.Last(label)
+	if err != nil {
+		return err
+	}
+	stream := run.Filter.Stream
+	if f.stream != "" {
+		stream = f.stream
+	}
+	log, err := store.Open(run, stream)
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+
+	var opts filter.Options
+	switch {
+	case full:
+	case lines != (filter.Range{}) || f.head > 0 || f.tail > 0 || f.grep != "":
+		opts = f.filterOptions()
+		opts.Parser = ""
+		opts.MaxTokens = 0
+		opts.Lines = lines
+	default:
+		opts = filter.Options{
+			Head:       run.Filter.Head,
+			Tail:       run.Filter.Tail,
+			Grep:       run.Filter.Grep,
+			GrepBefore: run.Filter.GrepBefore,
+			GrepAfter:  run.Filter.GrepAfter,
+			Parser:     run.Filter.Parser,
+			MaxTokens:  run.Filter.MaxTokens,
+		}
+		printFirstFailure(w, run)
+	}
+	if full {
+		return copyLines(w, log)
+	}
+	out, stats, err := filterLog(log, opts)
+	if err != nil {
+		return err
+	}
+
+	if len(out) > 0 {
+		fmt.Fprintf(w, "%s\n", strings.TrimSuffix(string(out), "\n"))
+	}
+	// Markers in the output already count what they stand for
+	switch omitted := stats.Omitted(); {
+	case omitted > stats.Marked:
+		fmt.Fprintf(w, "… %s lines omitted (use hush last %s--full)\n", filter.GroupThousands(omitted), labelArg(label))
+	case omitted > 0:
+		fmt.Fprintf(w, "… use hush last %s--full for the full output\n", labelArg(label))
+	}
+	return nil
+}
+
+// filterLog applies opts to a recorded log. It is read line by line, except
+// for a parser, which needs the whole output and is skipped for a log too
+// large to hold in memory.
+func filterLog(log *os.File, opts filter.Options) ([]byte, filter.Stats, error) {
+	if opts.Parser != "" {
+		if info, err := log.Stat(); err == nil && info.Size() <= runner.DefaultMaxCapture {
+			raw, err := io.ReadAll(log)
+			if err != nil {
+				return nil, filter.Stats{}, err
+			}
+			cleaned, _ := filter.Apply(raw, filter.Options{StripANSI: true})
+			out, stats := filter.Apply(cleaned, opts)
+			return out, stats, nil
+		}
+		opts.Parser = ""
+	}
+	opts.StripANSI = true
+	return filter.ApplyReader(log, opts)
+}
+
+// copyLines copies r to w line by line, stripping ANSI escapes, and ends the
+// last line with a line break.
+func copyLines(w io.Writer, r io.Reader) error {
+	br := bufio.NewReader(r)
+	for {
+		text, err := br.ReadBytes('\n')
+		if len(text) > 0 {
+			text = filter.StripANSI(bytes.TrimSuffix(text, []byte("\n")))
+			if _, err := fmt.Fprintf(w, "%s\n", text); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// printFirstFailure shows the failed first attempt of a flaky run ahead of
+// the output of the attempt that passed.
+func printFirstFailure(w io.Writer, run *history.Run) {
+	first := run.FirstFailure
+	if first == nil {
+		return
+	}
+	fmt.Fprintf(w, "attempt 1/%d failed (exit code %d):\n", run.Attempts, first.ExitCode)
+	if first.Output != "" {
+		fmt.Fprintln(w, strings.TrimSuffix(first.Output, "\n"))
+	}
+	fmt.Fprintf(w, "attempt %d/%d passed:\n", run.Attempt, run.Attempts)
+}
+
+// parseLineRange parses a --lines value: "100-200", "100-" or "100".
+func parseLineRange(s string) (filter.Range, error) {
+	if s == "" {
+		return filter.Range{}, nil
+	}
+	from, to, ranged := strings.Cut(s, "-")
+	start, err := strconv.Atoi(from)
+	if err != nil || start < 1 {
+		return filter.Range{}, fmt.Errorf("invalid --lines %q (want e.g. 100-200)", s)
+	}
+	r := filter.Range{Start: start, End: start}
+	if ranged {
+		r.End = 0
+		if to != "" {
+			if r.End, err = strconv.Atoi(to); err != nil || r.End < start {
+				return filter.Range{}, fmt.Errorf("invalid --lines %q (want e.g. 100-200)", s)
+			}
+		}
+	}
+	return r, nil
+}
+
+// openRuns enables recording of runs for hush last, and caching of checks
+// from cfg.
+func openRuns(cfg *config.Config) {
+	runs = runStore(cfg)
+	if cfg != nil {
+		checkCache = cacheStore(cfg)
+	}
+}
+
+// runStore returns the run history next to .hush.yaml or, if there is no
+// config file, in the user's cache directory. It returns nil if there is
+// neither.
+func runStore(cfg *config.Config) *history.Store {
+	if cfg == nil {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return nil
+		}
+		return &history.Store{Dir: filepath.Join(dir, "hush", "runs")}
+	}
+	return &history.Store{
+		Dir:  filepath.Join(cfg.Dir, ".hush", "runs"),
+		Keep: cfg.Defaults.KeepRuns,
+	}
+}
+
+// recordRun saves result for hush last, reporting whether it was saved.
+// Recording is best effort: a read-only checkout must not fail the run.
+func recordRun(result *runner.Result, f sharedFlags, first *output.Failure) bool {
+	if runs == nil {
+		return false
+	}
+	opts := f.filterOptions()
+	err := runs.Save(&history.Run{
+		Label:    result.Label,
+		Command:  result.Command,
+		ExitCode: result.ExitCode,
+		Started:  time.Now().Add(-result.Duration),
+		Duration: result.Duration,
+		TimedOut: result.TimedOut,
+		Attempt:  result.Attempt,
+		Attempts: result.Attempts,
+		Filter: history.Filter{
+			Head:       opts.Head,
+			Tail:       opts.Tail,
+			Grep:       opts.Grep,
+			GrepBefore: opts.GrepBefore,
+			GrepAfter:  opts.GrepAfter,
+			Parser:     opts.Parser,
+			MaxTokens:  opts.MaxTokens,
+			Stream:     f.stream,
+		},
+		FirstFailure: historyFailure(first),
+	}, history.Output{
+		Combined:     result.Output,
+		CombinedFile: result.Spill,
+		Stdout:       result.Stdout,
+		Stderr:       result.Stderr,
+	})
+	return err == nil
+}
+
+func historyFailure(f *output.Failure) *history.Failure {
+	if f == nil {
+		return nil
+	}
+	return &history.Failure{ExitCode: f.ExitCode, Output: string(f.Output)}
+}
+
+func labelArg(label string) string {
+	if label == "" {
+		return ""
+	}
+	return shellQuote(label) + " "
+}
+
+// shellQuote quotes s for a POSIX shell if it contains anything but
+// letters, digits and -_./:.
+func shellQuote(s string) string {
+	safe := s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:", r))
+	}) < 0
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
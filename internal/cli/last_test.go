@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/alfranz/hush/internal/filter"
+)
+
+func TestParseLineRange(t *testing.T) {
+	tests := []struct {
+		in   string
+		want filter.Range
+		err  bool
+	}{
+		{"", filter.Range{}, false},
+		{"100-200", filter.Range{Start: 100, End: 200}, false},
+		{"100-", filter.Range{Start: 100}, false},
+		{"7", filter.Range{Start: 7, End: 7}, false},
+		{"0-5", filter.Range{}, true},
+		{"20-10", filter.Range{}, true},
+		{"a-b", filter.Range{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseLineRange(tt.in)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("parseLineRange(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"pytest":     "pytest",
+		"go test":    "'go test'",
+		"it's":       `'it'\''s'`,
+		"src/app.py": "src/app.py",
+	}
+	for in, want := range tests {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/alfranz/hush/internal/config"
+)
+
+// printCheckList prints the configured checks in the order "hush all" runs them.
+func printCheckList(w io.Writer, cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, name := range cfg.CheckNames() {
+		fmt.Fprintf(tw, "%s\t%s\n", name, cfg.Checks[name].Cmd)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,249 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alfranz/hush/internal/config"
+	"github.com/alfranz/hush/internal/mcp"
+	"github.com/alfranz/hush/internal/output"
+	"github.com/spf13/cobra"
+)
+
+func newMCPCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "mcp",
+		Short: "Serve checks as tools over the Model Context Protocol on stdio",
+		Long: "Speaks the Model Context Protocol on stdin and stdout. Each check from .hush.yaml becomes a\n" +
+			"tool, next to generic run, batch and last tools. Results come back as text and as the\n" +
+			"structured content of --format json.",
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := loadConfigQuiet()
+			f := applyDefaults(cmd, flags, cfg)
+			if err := f.validate(); err != nil {
+				return err
+			}
+			openRuns(cfg)
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			server := &mcp.Server{Name: "hush", Version: buildVersion(), Tools: mcpTools(cfg, f)}
+			return server.Serve(ctx, os.Stdin, os.Stdout)
+		},
+	}
+}
+
+// buildVersion returns the module version hush was built from.
+func buildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// mcpTools returns a tool per check in cfg, then the generic tools whose
+// names no check has taken. f holds the flags and defaults for run and batch.
+func mcpTools(cfg *config.Config, f sharedFlags) []mcp.Tool {
+	var tools []mcp.Tool
+	if cfg != nil {
+		for _, name := range cfg.CheckNames() {
+			tools = append(tools, checkTool(cfg, name))
+		}
+	}
+	for _, t := range []mcp.Tool{runTool(f), batchTool(cfg, f), lastTool(cfg, f)} {
+		if !slices.ContainsFunc(tools, func(c mcp.Tool) bool { return c.Name == t.Name }) {
+			tools = append(tools, t)
+		}
+	}
+	return tools
+}
+
+func checkTool(cfg *config.Config, name string) mcp.Tool {
+	check := cfg.Checks[name]
+	desc := fmt.Sprintf("Run the %s check from .hush.yaml: %s", name, check.Cmd)
+	if len(check.Needs) > 0 {
+		desc += fmt.Sprintf(" (after %s)", strings.Join(check.Needs, ", "))
+	}
+	return mcp.Tool{
+		Name:        name,
+		Description: desc + ". Prints ✓ on success and filtered output on failure.",
+		Call: func(ctx context.Context, _ json.RawMessage) (mcp.Result, error) {
+			jobs := checkJobs(cfg, cfg.WithNeeds(name))
+			for i := range jobs {
+				if err := jobs[i].flags.validate(); err != nil {
+					return mcp.Result{}, err
+				}
+				jobs[i].flags.live = false
+			}
+			return toolResult(checkFlags(config.Check{}, cfg).durations, func(out output.Formatter) (bool, error) {
+				summary, err := runJobs(ctx, out, jobs, batchOptions{parallel: cfg.Defaults.Parallel})
+				return summary.firstFailCode != 0, err
+			})
+		},
+	}
+}
+
+func runTool(f sharedFlags) mcp.Tool {
+	return mcp.Tool{
+		Name:        "run",
+		Description: "Run a shell command. Prints ✓ on success and filtered output on failure.",
+		InputSchema: objectSchema(map[string]any{
+			"command": stringSchema("Shell command to run"),
+			"label":   stringSchema("Custom label for the summary line"),
+			"timeout": stringSchema("Kill the command after this duration, e.g. 30s or 5m"),
+		}, "command"),
+		Call: func(ctx context.Context, raw json.RawMessage) (mcp.Result, error) {
+			var args struct {
+				Command string `json:"command"`
+				Label   string `json:"label"`
+				Timeout string `json:"timeout"`
+			}
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return mcp.Result{}, err
+			}
+			if args.Command == "" {
+				return mcp.Result{}, fmt.Errorf("command is required")
+			}
+			f := withToolDefaults(f, args.Command)
+			f.live = false
+			if args.Label != "" {
+				f.label = args.Label
+			}
+			if args.Timeout != "" {
+				timeout, err := time.ParseDuration(args.Timeout)
+				if err != nil {
+					return mcp.Result{}, fmt.Errorf("invalid timeout: %w", err)
+				}
+				f.timeout = timeout
+			}
+			return toolResult(f.durations, func(out output.Formatter) (bool, error) {
+				result, err := runCommand(ctx, args.Command, f, false)
+				if err != nil {
+					return false, err
+				}
+				printReport(out, result, f)
+				return result.ExitCode != 0, nil
+			})
+		},
+	}
+}
+
+func batchTool(cfg *config.Config, f sharedFlags) mcp.Tool {
+	return mcp.Tool{
+		Name:        "batch",
+		Description: "Run shell commands in order and summarize them. Stops on the first failure unless continue is set.",
+		InputSchema: objectSchema(map[string]any{
+			"commands": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Shell commands to run",
+			},
+			"continue": map[string]any{"type": "boolean", "description": "Continue running after a failure"},
+			"parallel": map[string]any{"type": "integer", "minimum": 1, "description": "Run up to N commands concurrently"},
+		}, "commands"),
+		Call: func(ctx context.Context, raw json.RawMessage) (mcp.Result, error) {
+			var args struct {
+				Commands []string `json:"commands"`
+				Continue bool     `json:"continue"`
+				Parallel int      `json:"parallel"`
+			}
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return mcp.Result{}, err
+			}
+			if len(args.Commands) == 0 {
+				return mcp.Result{}, fmt.Errorf("commands is required")
+			}
+			f := f
+			f.label = ""
+			f.live = false
+			opts := batchOptions{continueOnError: args.Continue, parallel: args.Parallel}
+			if cfg != nil {
+				opts.continueOnError = opts.continueOnError || cfg.Defaults.Continue
+				if opts.parallel == 0 {
+					opts.parallel = cfg.Defaults.Parallel
+				}
+			}
+			jobs := make([]job, len(args.Commands))
+			for i, command := range args.Commands {
+				jobs[i] = job{command: command, flags: withToolDefaults(f, command)}
+			}
+			return toolResult(f.durations, func(out output.Formatter) (bool, error) {
+				summary, err := runJobsWithSummary(ctx, out, jobs, opts)
+				return summary.firstFailCode != 0, err
+			})
+		},
+	}
+}
+
+func lastTool(cfg *config.Config, f sharedFlags) mcp.Tool {
+	return mcp.Tool{
+		Name:        "last",
+		Description: "Show the output of the most recent run, or the most recent run with a label, without rerunning it.",
+		InputSchema: objectSchema(map[string]any{
+			"label": stringSchema("Label of the run, e.g. pytest; the most recent run if empty"),
+			"full":  map[string]any{"type": "boolean", "description": "Print the complete output instead of the filtered one"},
+			"lines": stringSchema("Print only this range of lines, e.g. 100-200"),
+		}),
+		Call: func(ctx context.Context, raw json.RawMessage) (mcp.Result, error) {
+			var args struct {
+				Label string `json:"label"`
+				Full  bool   `json:"full"`
+				Lines string `json:"lines"`
+			}
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return mcp.Result{}, err
+			}
+			lines, err := parseLineRange(args.Lines)
+			if err != nil {
+				return mcp.Result{}, err
+			}
+			var buf bytes.Buffer
+			if err := printLast(&buf, runStore(cfg), args.Label, args.Full, lines, f); err != nil {
+				return mcp.Result{}, err
+			}
+			return mcp.Result{Text: buf.String()}, nil
+		},
+	}
+}
+
+// toolResult renders what run prints as text, and as --format json for
+// structured content. run reports whether a command failed.
+func toolResult(durations bool, run func(out output.Formatter) (bool, error)) (mcp.Result, error) {
+	opts := output.FormatOptions{Durations: durations}
+	var text, structured bytes.Buffer
+	textOut, _ := output.NewFormatter(&text, "text", opts)
+	jsonOut, _ := output.NewFormatter(&structured, "json", opts)
+	out := output.Tee(textOut, jsonOut)
+
+	failed, err := run(out)
+	if err != nil {
+		return mcp.Result{}, err
+	}
+	if err := out.Close(); err != nil {
+		return mcp.Result{}, err
+	}
+	return mcp.Result{Text: text.String(), Structured: structured.Bytes(), IsError: failed}, nil
+}
+
+func objectSchema(properties map[string]any, required ...string) map[string]any {
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringSchema(description string) map[string]any {
+	return map[string]any{"type": "string", "description": description}
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/alfranz/hush/internal/config"
+	"github.com/alfranz/hush/internal/mcp"
+)
+
+func TestMCPTools(t *testing.T) {
+	cfg, err := config.Parse([]byte(`checks:
+  lint:
+    cmd: "echo 'app.py:1: unused import' && exit 1"
+    label: lint
+  run:
+    cmd: "true"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Dir = t.TempDir()
+	openRuns(cfg)
+	t.Cleanup(func() { runs, checkCache = nil, nil })
+
+	tools := make(map[string]mcp.Tool)
+	var names []string
+	for _, tool := range mcpTools(cfg, sharedFlags{}) {
+		tools[tool.Name] = tool
+		names = append(names, tool.Name)
+	}
+	// A check named like a generic tool takes its place
+	if got := strings.Join(names, " "); got != "lint run batch last" {
+		t.Errorf("unexpected tools: %s", got)
+	}
+
+	call := func(name, args string) mcp.Result {
+		t.Helper()
+		result, err := tools[name].Call(t.Context(), json.RawMessage(args))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	result := call("lint", `{}`)
+	if !result.IsError || !strings.HasPrefix(result.Text, "✗ lint\n") {
+		t.Errorf("expected lint to fail, got %+v", result)
+	}
+	var report struct {
+		Status   string `json:"status"`
+		ExitCode int    `json:"exit_code"`
+		Output   string `json:"output"`
+	}
+	if err := json.Unmarshal(result.Structured, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != "fail" || report.ExitCode != 1 || report.Output != "app.py:1: unused import" {
+		t.Errorf("unexpected structured content: %+v", report)
+	}
+
+	result = call("batch", `{"commands": ["echo one", "exit 3"]}`)
+	if !result.IsError || result.Text != "✓ echo\n✗ exit\n" {
+		t.Errorf("unexpected batch result: %+v", result)
+	}
+
+	result = call("last", `{"label": "lint"}`)
+	if result.IsError || result.Text != "app.py:1: unused import\n" {
+		t.Errorf("unexpected last result: %+v", result)
+	}
+}
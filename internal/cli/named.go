@@ -1,37 +1,45 @@
 package cli
 
 import (
-	"context"
 	"maps"
 	"os"
 	"slices"
 
 	"github.com/alfranz/hush/internal/config"
-	"github.com/alfranz/hush/internal/filter"
-	"github.com/alfranz/hush/internal/output"
 	"github.com/alfranz/hush/internal/runner"
+	"github.com/alfranz/hush/internal/watch"
 	"github.com/spf13/cobra"
 )
 
-func registerNamedChecks(root *cobra.Command) {
+var allFlags struct {
+	parallel int
+	changed  bool
+	since    string
+}
+
+func registerNamedChecks(root *cobra.Command) error {
 	cfg, err := config.Load()
-	if err != nil || cfg == nil {
-		return
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		return nil
 	}
+	runner.AddWrappers(cfg.Wrappers...)
 
 	// Collect check names in order for "all" command
-	checkNames := slices.Collect(maps.Keys(cfg.Checks))
+	checkNames := cfg.CheckNames()
 
 	// Register individual check commands
-	for name, check := range cfg.Checks {
-		check := check
+	for _, name := range checkNames {
+		name, check := name, cfg.Checks[name]
 		cmd := &cobra.Command{
 			Use:           name,
 			Short:         "Run " + name + " check from .hush.yaml",
 			SilenceErrors: true,
 			SilenceUsage:  true,
 			RunE: func(cmd *cobra.Command, args []string) error {
-				return runNamedCheck(check, cfg)
+				return runNamedCheck(name, check, cfg)
 			},
 		}
 		root.AddCommand(cmd)
@@ -44,102 +52,298 @@ func registerNamedChecks(root *cobra.Command) {
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var commands []string
-			for _, name := range checkNames {
-				commands = append(commands, cfg.Checks[name].Cmd)
+			jobs := checkJobs(cfg, checkNames)
+			for _, j := range jobs {
+				if err := j.flags.validate(); err != nil {
+					return err
+				}
 			}
+			if allFlags.changed || allFlags.since != "" {
+				files, err := changedFiles(cfg.Dir, allFlags.since)
+				if err != nil {
+					return err
+				}
+				relevant := changedChecks(cfg, checkNames, files)
+				for i, name := range checkNames {
+					if !slices.Contains(relevant, name) {
+						jobs[i].skip = "no relevant changes"
+					}
+				}
+			}
+			openRuns(cfg)
 			// Use defaults.continue for "all" command
 			continueOnError := cfg.Defaults.Continue
 			if cmd.Flags().Changed("continue") {
 				continueOnError, _ = cmd.Flags().GetBool("continue")
 			}
-			batchShared := flags
-			if cfg != nil {
-				if batchShared.warnPattern == "" {
-					batchShared.warnPattern = cfg.Defaults.WarnPattern
-				}
-				if batchShared.warnTail == 0 {
-					batchShared.warnTail = cfg.Defaults.WarnTail
-				}
-			}
-			return executeBatch(commands, batchShared, continueOnError)
+			return executeBatch(jobs, batchOptions{
+				continueOnError: continueOnError,
+				parallel:        resolveParallel(cmd, allFlags.parallel, cfg),
+				output:          checkFlags(config.Check{}, cfg),
+			})
 		},
 	}
 	allCmd.Flags().BoolP("continue", "", false, "Continue running after a failure")
-	root.AddCommand(allCmd)
+	allCmd.Flags().BoolVar(&allFlags.changed, "changed", false, "Only run checks whose paths: globs match files changed in git")
+	allCmd.Flags().StringVar(&allFlags.since, "since", "", "With --changed, compare against where the branch forked off this ref (e.g. origin/main) instead of HEAD")
+	addParallelFlags(allCmd, &allFlags.parallel)
+	root.AddCommand(allCmd, newWatchCmd(cfg, checkNames))
+	return nil
 }
 
-func runNamedCheck(check config.Check, cfg *config.Config) error {
-	result, err := runner.Run(context.Background(), runner.Options{
-		Command: check.Cmd,
-		Label:   check.Label,
+func runNamedCheck(name string, check config.Check, cfg *config.Config) error {
+	f := checkFlags(check, cfg)
+	if err := f.validate(); err != nil {
+		return err
+	}
+	openRuns(cfg)
+	out, err := newFormatter(f)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+	// The checks this one needs run first; it is skipped if any fail.
+	summary, err := runJobs(ctx, out, checkJobs(cfg, cfg.WithNeeds(name)), batchOptions{
+		parallel: cfg.Defaults.Parallel,
 	})
 	if err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
 		return err
 	}
+	if ctx.Err() != nil {
+		os.Exit(interruptedExitCode)
+	}
+	if summary.firstFailCode != 0 {
+		os.Exit(summary.firstFailCode)
+	}
+	return nil
+}
+
+// checkJobs builds batch jobs for the named checks, wiring up needs so
+// that each job waits on the checks it depends on.
+func checkJobs(cfg *config.Config, names []string) []job {
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		index[name] = i
+	}
+
+	jobs := make([]job, len(names))
+	for i, name := range names {
+		check := cfg.Checks[name]
+		jobs[i] = job{command: check.Cmd, flags: checkFlags(check, cfg), name: name, inputs: check.Inputs}
+		for _, dep := range check.Needs {
+			if d, ok := index[dep]; ok {
+				jobs[i].needs = append(jobs[i].needs, d)
+			}
+		}
+	}
+	return jobs
+}
+
+// withDependents returns the checks among names that match, together with
+// the checks that need them, in the order of names.
+func withDependents(cfg *config.Config, names []string, match func(config.Check) bool) []string {
+	selected := make(map[string]bool)
+	for _, name := range names {
+		selected[name] = match(cfg.Checks[name])
+	}
+	// Dependencies come first in names, so one pass reaches every dependent
+	for _, name := range names {
+		for _, dep := range cfg.Checks[name].Needs {
+			selected[name] = selected[name] || selected[dep]
+		}
+	}
+	var matched []string
+	for _, name := range names {
+		if selected[name] {
+			matched = append(matched, name)
+		}
+	}
+	return matched
+}
+
+// matchesAny reports whether any of globs, relative to the config file,
+// matches any of paths.
+func matchesAny(globs, paths []string) bool {
+	for _, p := range paths {
+		for _, glob := range globs {
+			if watch.Match(glob, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
 
-	// Precedence: CLI flags > per-check config > defaults > zero
-	filterOpts := filter.Options{
-		StripANSI: true,
+// envPairs returns env as KEY=VALUE pairs sorted by key.
+func envPairs(env map[string]string) []string {
+	pairs := make([]string, 0, len(env))
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		pairs = append(pairs, key+"="+env[key])
 	}
+	return pairs
+}
+
+// checkFlags resolves the effective flags for a named check.
+// Precedence: CLI flags > per-check config > defaults > zero
+func checkFlags(check config.Check, cfg *config.Config) sharedFlags {
+	f := sharedFlags{label: check.Label}
 
 	// Start with defaults
 	if cfg != nil {
-		filterOpts.Head = cfg.Defaults.Head
-		filterOpts.Tail = cfg.Defaults.Tail
-		filterOpts.Grep = cfg.Defaults.Grep
+		f.head = cfg.Defaults.Head
+		f.tail = cfg.Defaults.Tail
+		f.grep = cfg.Defaults.Grep
+		f.parser = cfg.Defaults.Parser
+		f.maxTokens = cfg.Defaults.MaxTokens
+		f.grepContext = cfg.Defaults.GrepContext
+		f.grepBefore = cfg.Defaults.GrepBefore
+		f.grepAfter = cfg.Defaults.GrepAfter
+		f.stream = cfg.Defaults.Stream
+		f.live = cfg.Defaults.Live
+		f.maxCapture = cfg.Defaults.MaxCapture
+		f.retries = cfg.Defaults.Retries
+		f.retryDelay = cfg.Defaults.RetryDelay
+		f.retryOn = cfg.Defaults.RetryOn
+		f.defaultEnv = envPairs(cfg.Defaults.Env)
+		f.shell = cfg.Defaults.Shell
+		f.warnPattern = cfg.Defaults.WarnPattern
+		f.warnTail = cfg.Defaults.WarnTail
+		f.timeout = cfg.Defaults.Timeout
+		f.slow = cfg.Defaults.Slow
+		f.durations = cfg.Defaults.Durations
 	}
 
 	// Per-check config overrides defaults
 	if check.Head > 0 {
-		filterOpts.Head = check.Head
+		f.head = check.Head
 	}
 	if check.Tail > 0 {
-		filterOpts.Tail = check.Tail
+		f.tail = check.Tail
 	}
 	if check.Grep != "" {
-		filterOpts.Grep = check.Grep
+		f.grep = check.Grep
+	}
+	if check.Parser != "" {
+		f.parser = check.Parser
+	}
+	if check.MaxTokens > 0 {
+		f.maxTokens = check.MaxTokens
+	}
+	if check.GrepContext > 0 {
+		f.grepContext = check.GrepContext
+	}
+	if check.GrepBefore > 0 {
+		f.grepBefore = check.GrepBefore
+	}
+	if check.GrepAfter > 0 {
+		f.grepAfter = check.GrepAfter
+	}
+	if check.Stream != "" {
+		f.stream = check.Stream
+	}
+	if check.Retries > 0 {
+		f.retries = check.Retries
+	}
+	if check.RetryDelay > 0 {
+		f.retryDelay = check.RetryDelay
+	}
+	if check.RetryOn != "" {
+		f.retryOn = check.RetryOn
+	}
+	if check.Shell != "" {
+		f.shell = check.Shell
+	}
+	if check.Dir != "" {
+		f.dir = cfg.Path(check.Dir)
+	}
+	if check.EnvFile != "" {
+		f.envFile = cfg.Path(check.EnvFile)
+	}
+	f.env = append(f.env, envPairs(check.Env)...)
+	if check.WarnPattern != "" {
+		f.warnPattern = check.WarnPattern
+	}
+	if check.WarnTail > 0 {
+		f.warnTail = check.WarnTail
+	}
+	if check.Timeout > 0 {
+		f.timeout = check.Timeout
+	}
+	if check.Slow > 0 {
+		f.slow = check.Slow
 	}
 
 	// CLI flags override everything
 	if flags.head > 0 {
-		filterOpts.Head = flags.head
+		f.head = flags.head
 	}
 	if flags.tail > 0 {
-		filterOpts.Tail = flags.tail
+		f.tail = flags.tail
 	}
 	if flags.grep != "" {
-		filterOpts.Grep = flags.grep
+		f.grep = flags.grep
 	}
-
-	warnFlags := sharedFlags{}
-	if cfg != nil {
-		warnFlags.warnPattern = cfg.Defaults.WarnPattern
-		warnFlags.warnTail = cfg.Defaults.WarnTail
+	if flags.parser != "" {
+		f.parser = flags.parser
 	}
-	if check.WarnPattern != "" {
-		warnFlags.warnPattern = check.WarnPattern
+	if flags.maxTokens > 0 {
+		f.maxTokens = flags.maxTokens
 	}
-	if check.WarnTail > 0 {
-		warnFlags.warnTail = check.WarnTail
+	if flags.grepContext > 0 {
+		f.grepContext = flags.grepContext
+	}
+	if flags.grepBefore > 0 {
+		f.grepBefore = flags.grepBefore
+	}
+	if flags.grepAfter > 0 {
+		f.grepAfter = flags.grepAfter
+	}
+	if flags.stream != "" {
+		f.stream = flags.stream
 	}
 	if flags.warnPattern != "" {
-		warnFlags.warnPattern = flags.warnPattern
+		f.warnPattern = flags.warnPattern
 	}
 	if flags.warnTail > 0 {
-		warnFlags.warnTail = flags.warnTail
+		f.warnTail = flags.warnTail
 	}
-	warnFlags.head = filterOpts.Head
-	warnFlags.tail = filterOpts.Tail
-	warnFlags.grep = filterOpts.Grep
-
-	filtered := filter.Apply(result.Output, filterOpts)
-	warnings := buildWarningReport(result.Output, warnFlags)
-
-	output.PrintResult(os.Stdout, result.Label, result.ExitCode, filtered, warnings.count, warnings.lines)
-
-	if result.ExitCode != 0 {
-		os.Exit(result.ExitCode)
+	if flags.timeout > 0 {
+		f.timeout = flags.timeout
 	}
-	return nil
+	if flags.slow > 0 {
+		f.slow = flags.slow
+	}
+	if flags.durations {
+		f.durations = true
+	}
+	if flags.live {
+		f.live = true
+	}
+	if flags.maxCapture != "" {
+		f.maxCapture = flags.maxCapture
+	}
+	if flags.retries > 0 {
+		f.retries = flags.retries
+	}
+	if flags.retryDelay > 0 {
+		f.retryDelay = flags.retryDelay
+	}
+	if flags.retryOn != "" {
+		f.retryOn = flags.retryOn
+	}
+	if flags.dir != "" {
+		f.dir = flags.dir
+	}
+	f.env = append(f.env, flags.env...)
+	f.noCache = flags.noCache
+	f.format = flags.format
+	f.junit = flags.junit
+
+	return withToolDefaults(f, check.Cmd)
 }
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/alfranz/hush/internal/config"
+)
+
+func TestCheckFlagsDirAndEnv(t *testing.T) {
+	cfg := &config.Config{
+		Dir:      "/repo",
+		Defaults: config.Defaults{Env: map[string]string{"CI": "1", "MODE": "default"}},
+	}
+	check := config.Check{
+		Cmd:     "go test ./...",
+		Dir:     "services/api",
+		EnvFile: ".env",
+		Env:     map[string]string{"MODE": "check"},
+	}
+
+	f := checkFlags(check, cfg)
+	if f.dir != filepath.Join("/repo", "services", "api") || f.envFile != filepath.Join("/repo", ".env") {
+		t.Errorf("expected paths relative to the config file, got dir %q env-file %q", f.dir, f.envFile)
+	}
+	if want := []string{"CI=1", "MODE=default"}; !slices.Equal(f.defaultEnv, want) {
+		t.Errorf("unexpected default env: %q, want %q", f.defaultEnv, want)
+	}
+	if want := []string{"MODE=check"}; !slices.Equal(f.env, want) {
+		t.Errorf("unexpected env: %q, want %q", f.env, want)
+	}
+}
+
+func TestRunCommandEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	os.WriteFile(path, []byte("GREETING=file\nTARGET=world\n"), 0644)
+
+	result, err := runCommand(t.Context(), "echo $GREETING $TARGET", sharedFlags{envFile: path, env: []string{"GREETING=hello"}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result.Output) != "hello world\n" {
+		t.Errorf("expected env to override the env file, got %q", result.Output)
+	}
+}
+
+func TestRunCommandEnvFileOverridesDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	os.WriteFile(path, []byte("GREETING=file\n"), 0644)
+
+	f := sharedFlags{defaultEnv: []string{"GREETING=default", "TARGET=world"}, envFile: path}
+	result, err := runCommand(t.Context(), "echo $GREETING $TARGET", f, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result.Output) != "file world\n" {
+		t.Errorf("expected the env file to override defaults.env, got %q", result.Output)
+	}
+}
@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/alfranz/hush/internal/filter"
+	"github.com/alfranz/hush/internal/output"
+	"github.com/alfranz/hush/internal/runner"
+)
+
+// newFormatter returns the stdout formatter selected by --format, also
+// writing a JUnit report when --junit is set.
+func newFormatter(f sharedFlags) (output.Formatter, error) {
+	out, err := output.NewFormatter(os.Stdout, f.format, output.FormatOptions{
+		Durations: f.durations,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if f.junit != "" {
+		out = output.Tee(out, output.NewJUnitFormatter(f.junit))
+	}
+	return out, nil
+}
+
+// buildReport filters the command output according to f and classifies result.
+func buildReport(result *runner.Result, f sharedFlags) output.Report {
+	filtered, stats := filterOutput(result, f)
+
+	r := output.Report{
+		Label:       result.Label,
+		Command:     result.Command,
+		ExitCode:    result.ExitCode,
+		Duration:    result.Duration,
+		Output:      filtered,
+		TotalLines:  stats.TotalLines,
+		ShownLines:  stats.KeptLines,
+		MarkedLines: stats.Marked,
+		Elided:      stats.Elided,
+		Attempt:     result.Attempt,
+		Attempts:    result.Attempts,
+	}
+
+	switch {
+	case result.TimedOut:
+		r.Status = output.StatusTimeout
+		r.Timeout = result.Timeout
+	case result.ExitCode != 0:
+		r.Status = output.StatusFail
+	default:
+		warnings := buildWarningReport(result.Output, result.Spill, f)
+		r.WarningCount = warnings.count
+		r.Warnings = warnings.lines
+		r.Status = output.StatusPass
+		if warnings.count > 0 {
+			r.Status = output.StatusWarn
+		}
+		if f.slow > 0 && result.Duration > f.slow {
+			r.Status = output.StatusWarn
+			r.Slow = f.slow
+		}
+		if r.Flaky() {
+			r.Status = output.StatusWarn
+			if first := result.FirstFailure; first != nil {
+				filtered, _ := filterOutput(first, f)
+				r.FirstFailure = &output.Failure{ExitCode: first.ExitCode, Output: filtered}
+			}
+		}
+	}
+	return r
+}
+
+// filterOutput applies the filters selected by f to the stream selected by
+// --stream. If the output outgrew --max-capture, grep searches the complete
+// output spilled to disk; other filters see the head and tail kept in
+// memory.
+func filterOutput(result *runner.Result, f sharedFlags) ([]byte, filter.Stats) {
+	opts := f.filterOptions()
+	if result.Spill != "" && f.grep != "" && (f.stream == "" || f.stream == "both") {
+		if filtered, stats, err := grepFile(result.Spill, opts); err == nil {
+			return filtered, stats
+		}
+	}
+
+	raw := streamOutput(f.stream, result.Output, result.Stdout, result.Stderr)
+	gap := result.OutputGap
+	switch f.stream {
+	case "stdout":
+		gap = result.StdoutGap
+	case "stderr":
+		gap = result.StderrGap
+	}
+	cleaned, _ := filter.Apply(raw, filter.Options{StripANSI: true})
+	filtered, stats := filter.Apply(cleaned, opts)
+	return filtered, stats.WithGap(gap.Line, gap.Lines)
+}
+
+// grepFile filters the output in path line by line. Parsers need the
+// whole output in memory and are skipped.
+func grepFile(path string, opts filter.Options) ([]byte, filter.Stats, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, filter.Stats{}, err
+	}
+	defer file.Close()
+	opts.StripANSI = true
+	return filter.ApplyReader(file, opts)
+}
+
+// streamOutput returns the output selected by --stream: stdout, stderr or
+// by default both, interleaved.
+func streamOutput(stream string, combined, stdout, stderr []byte) []byte {
+	switch stream {
+	case "stdout":
+		return stdout
+	case "stderr":
+		return stderr
+	}
+	return combined
+}
+
+// skippedReport describes a job that did not run.
+func skippedReport(j job, reason string) output.Report {
+	return output.Report{
+		Label:      j.label(),
+		Command:    j.command,
+		Status:     output.StatusSkipped,
+		SkipReason: reason,
+	}
+}
+
+// cachedReport describes a job that was not run because it already passed
+// with the same inputs.
+func cachedReport(j job) output.Report {
+	// Label the check as a run would
+	label := j.flags.label
+	if label == "" {
+		label = runner.DeriveLabel(j.command)
+	}
+	return output.Report{
+		Label:   label,
+		Command: j.command,
+		Status:  output.StatusPass,
+		Cached:  true,
+	}
+}
+
+// printReport filters the command output according to f and prints the
+// summary line for result, recording the run for hush last. It removes the
+// result's spill file and returns the printed report.
+func printReport(out output.Formatter, result *runner.Result, f sharedFlags) output.Report {
+	r := buildReport(result, f)
+	if recordRun(result, f, r.FirstFailure) {
+		r.Recall = "hush last " + shellQuote(r.Label) + " --full"
+	}
+	result.Close()
+	out.Result(r)
+	return r
+}
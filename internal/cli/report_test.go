@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alfranz/hush/internal/output"
+	"github.com/alfranz/hush/internal/runner"
+)
+
+func TestBuildReportStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		result runner.Result
+		flags  sharedFlags
+		want   output.Status
+	}{
+		{"pass", runner.Result{Output: []byte("ok\n")}, sharedFlags{}, output.StatusPass},
+		{"fail", runner.Result{ExitCode: 1}, sharedFlags{}, output.StatusFail},
+		{"warn", runner.Result{Output: []byte("warning: x\n")}, sharedFlags{warnPattern: "warning"}, output.StatusWarn},
+		{"timeout", runner.Result{ExitCode: runner.TimeoutExitCode, TimedOut: true}, sharedFlags{}, output.StatusTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildReport(&tt.result, tt.flags).Status; got != tt.want {
+				t.Errorf("expected status %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBuildReportLineCounts(t *testing.T) {
+	result := &runner.Result{ExitCode: 1, Output: []byte("a\nb\nc\nd\n")}
+	r := buildReport(result, sharedFlags{tail: 2})
+	if r.TotalLines != 4 || r.ShownLines != 2 {
+		t.Errorf("expected 2 of 4 lines shown, got %d of %d", r.ShownLines, r.TotalLines)
+	}
+	if string(r.Output) != "c\nd" {
+		t.Errorf("unexpected filtered output: %q", r.Output)
+	}
+}
+
+func TestBuildReportStream(t *testing.T) {
+	result := &runner.Result{
+		ExitCode: 1,
+		Output:   []byte("compiling\nerror: boom\ndone\n"),
+		Stdout:   []byte("compiling\ndone\n"),
+		Stderr:   []byte("error: boom\n"),
+	}
+	tests := map[string]string{
+		"":       "compiling\nerror: boom\ndone\n",
+		"both":   "compiling\nerror: boom\ndone\n",
+		"stdout": "compiling\ndone\n",
+		"stderr": "error: boom\n",
+	}
+	for stream, want := range tests {
+		if got := buildReport(result, sharedFlags{stream: stream}).Output; string(got) != want {
+			t.Errorf("stream %q: expected %q, got %q", stream, want, got)
+		}
+	}
+}
+
+func TestBuildReportSlow(t *testing.T) {
+	result := &runner.Result{Duration: 5 * time.Second}
+	r := buildReport(result, sharedFlags{slow: 3 * time.Second})
+	if r.Status != output.StatusWarn || r.Slow != 3*time.Second {
+		t.Errorf("expected slow warning, got status %q slow %s", r.Status, r.Slow)
+	}
+
+	r = buildReport(&runner.Result{Duration: time.Second}, sharedFlags{slow: 3 * time.Second})
+	if r.Status != output.StatusPass {
+		t.Errorf("expected pass under threshold, got %q", r.Status)
+	}
+
+	r = buildReport(&runner.Result{ExitCode: 1, Duration: 5 * time.Second}, sharedFlags{slow: 3 * time.Second})
+	if r.Status != output.StatusFail {
+		t.Errorf("expected failure to stay a failure, got %q", r.Status)
+	}
+}
+
+func TestBuildReportFlaky(t *testing.T) {
+	result := &runner.Result{
+		Attempt:      2,
+		Attempts:     3,
+		Output:       []byte("ok\n"),
+		FirstFailure: &runner.Result{ExitCode: 1, Output: []byte("setup\nECONNRESET\n")},
+	}
+	r := buildReport(result, sharedFlags{grep: "ECONN"})
+	if r.Status != output.StatusWarn || !r.Flaky() {
+		t.Errorf("expected a flaky warning, got status %q", r.Status)
+	}
+	if r.FirstFailure == nil || r.FirstFailure.ExitCode != 1 || string(r.FirstFailure.Output) != "ECONNRESET" {
+		t.Errorf("expected the filtered first failure, got %+v", r.FirstFailure)
+	}
+
+	r = buildReport(&runner.Result{ExitCode: 1, Attempt: 3, Attempts: 3}, sharedFlags{})
+	if r.Status != output.StatusFail || r.FirstFailure != nil {
+		t.Errorf("expected a plain failure when every attempt failed, got %q", r.Status)
+	}
+}
+
+func TestBuildReportGrepsSpilledOutput(t *testing.T) {
+	result, err := runner.Run(t.Context(), runner.Options{
+		Command:    "seq 1 20000; echo 'error: in the middle' >&2; seq 1 20000; exit 1",
+		MaxCapture: 4096,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer result.Close()
+	if result.Spill == "" {
+		t.Fatal("expected output to spill")
+	}
+
+	r := buildReport(result, sharedFlags{grep: "^error"})
+	if string(r.Output) != "error: in the middle" {
+		t.Errorf("expected grep to find the line dropped from memory, got %q", r.Output)
+	}
+	if r.TotalLines != 40001 || r.ShownLines != 1 {
+		t.Errorf("expected 1 of 40001 lines shown, got %d of %d", r.ShownLines, r.TotalLines)
+	}
+
+	r = buildReport(result, sharedFlags{})
+	if r.TotalLines != 40001 || r.ShownLines >= r.TotalLines {
+		t.Errorf("expected line counts to include uncaptured lines, got %d of %d", r.ShownLines, r.TotalLines)
+	}
+}
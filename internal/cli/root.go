@@ -2,18 +2,26 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/alfranz/hush/internal/config"
-	"github.com/alfranz/hush/internal/filter"
-	"github.com/alfranz/hush/internal/output"
 	"github.com/alfranz/hush/internal/runner"
 	"github.com/spf13/cobra"
 )
 
 var flags sharedFlags
 
+var listChecks bool
+
+// interruptedExitCode is the exit status after an interrupt, as a shell
+// reports a command killed by SIGINT.
+const interruptedExitCode = 130
+
 const logo = `
   _               _
  | |__  _   _ ___| |__
@@ -24,7 +32,7 @@ const logo = `
 
 func NewRootCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "hush [flags] <command>",
+		Use:   "hush [flags] <command> | -- <program> [args...]",
 		Short: "Context-efficient command runner",
 		Long:  logo + "Run commands. Save tokens.\n✓ on success. Filtered output on failure. Built for agents.",
 		Args:  cobra.ArbitraryArgs,
@@ -35,57 +43,117 @@ func NewRootCmd() *cobra.Command {
 	}
 
 	addSharedFlags(cmd, &flags)
-	cmd.AddCommand(newBatchCmd())
+	cmd.Flags().BoolVar(&listChecks, "list", false, "List checks from .hush.yaml in run order")
+	cmd.AddCommand(newBatchCmd(), newLastCmd(), newCacheCmd(), newMCPCmd(), newInitCmd())
 
 	return cmd
 }
 
 func Execute() {
-	cmd := NewRootCmd()
-
-	// Load config and register named check subcommands
-	registerNamedChecks(cmd)
-
-	if err := cmd.Execute(); err != nil {
+	if err := newCommand().Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
+// newCommand returns the root command with the named checks from
+// .hush.yaml registered. A broken config only fails the commands that read
+// it, so that hush init --force can still replace it.
+func newCommand() *cobra.Command {
+	cmd := NewRootCmd()
+	if err := registerNamedChecks(cmd); err != nil {
+		configErr := fmt.Errorf("invalid .hush.yaml: %w", err)
+		cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+			if !readsConfig(cmd) {
+				return nil
+			}
+			return configErr
+		}
+	}
+	return cmd
+}
+
+// readsConfig reports whether cmd uses .hush.yaml.
+func readsConfig(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		switch c.Name() {
+		case "init", "completion":
+			return false
+		}
+	}
+	return true
+}
+
 func runRoot(cmd *cobra.Command, args []string) error {
+	if listChecks {
+		printCheckList(os.Stdout, loadConfigQuiet())
+		return nil
+	}
 	if len(args) == 0 {
 		return cmd.Help()
 	}
 	command := args[0]
+	argv := cmd.ArgsLenAtDash() >= 0
+	if argv {
+		if cmd.ArgsLenAtDash() > 0 {
+			return errors.New("give either a quoted command or a command after --, not both")
+		}
+		command = joinArgs(args)
+	}
 
 	// Apply defaults from config if CLI flags not set
 	cfg := loadConfigQuiet()
-	f := applyDefaults(cmd, flags, cfg)
+	f := withToolDefaults(applyDefaults(cmd, flags, cfg), command)
+	if argv {
+		f.shell = runner.ShellNone
+	}
+	if err := f.validate(); err != nil {
+		return err
+	}
+	openRuns(cfg)
 
-	result, err := runner.Run(context.Background(), runner.Options{
-		Command: command,
-		Label:   f.label,
-	})
+	out, err := newFormatter(f)
 	if err != nil {
 		return err
 	}
 
-	filtered := filter.Apply(result.Output, filter.Options{
-		Head:      f.head,
-		Tail:      f.tail,
-		Grep:      f.grep,
-		StripANSI: true,
-	})
-	warnings := buildWarningReport(result.Output, f)
+	ctx, stop := interruptContext()
+	defer stop()
+	result, err := runCommand(ctx, command, f, true)
+	if err != nil {
+		return err
+	}
 
-	output.PrintResult(os.Stdout, result.Label, result.ExitCode, filtered, warnings.count, warnings.lines)
+	printReport(out, result, f)
+	if err := out.Close(); err != nil {
+		return err
+	}
 
+	if ctx.Err() != nil {
+		os.Exit(interruptedExitCode)
+	}
 	if result.ExitCode != 0 {
 		os.Exit(result.ExitCode)
 	}
 	return nil
 }
 
+// interruptContext returns a context cancelled on SIGINT or SIGTERM, so
+// that an interrupt stops the running commands' process groups rather than
+// leaving them behind.
+func interruptContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// joinArgs quotes args into the command line they were given as.
+func joinArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
 // loadConfigQuiet loads config without failing on error.
 func loadConfigQuiet() *config.Config {
 	cfg, _ := config.Load()
@@ -112,5 +180,51 @@ func applyDefaults(cmd *cobra.Command, f sharedFlags, cfg *config.Config) shared
 	if !cmd.Flags().Changed("warn-tail") && cfg.Defaults.WarnTail > 0 {
 		f.warnTail = cfg.Defaults.WarnTail
 	}
+	if !cmd.Flags().Changed("timeout") && cfg.Defaults.Timeout > 0 {
+		f.timeout = cfg.Defaults.Timeout
+	}
+	if !cmd.Flags().Changed("durations") && cfg.Defaults.Durations {
+		f.durations = true
+	}
+	if !cmd.Flags().Changed("slow") && cfg.Defaults.Slow > 0 {
+		f.slow = cfg.Defaults.Slow
+	}
+	if !cmd.Flags().Changed("parser") && cfg.Defaults.Parser != "" {
+		f.parser = cfg.Defaults.Parser
+	}
+	if !cmd.Flags().Changed("max-tokens") && cfg.Defaults.MaxTokens > 0 {
+		f.maxTokens = cfg.Defaults.MaxTokens
+	}
+	if !cmd.Flags().Changed("grep-context") && cfg.Defaults.GrepContext > 0 {
+		f.grepContext = cfg.Defaults.GrepContext
+	}
+	if !cmd.Flags().Changed("grep-before") && cfg.Defaults.GrepBefore > 0 {
+		f.grepBefore = cfg.Defaults.GrepBefore
+	}
+	if !cmd.Flags().Changed("grep-after") && cfg.Defaults.GrepAfter > 0 {
+		f.grepAfter = cfg.Defaults.GrepAfter
+	}
+	if !cmd.Flags().Changed("stream") && cfg.Defaults.Stream != "" {
+		f.stream = cfg.Defaults.Stream
+	}
+	if !cmd.Flags().Changed("live") && cfg.Defaults.Live {
+		f.live = true
+	}
+	if !cmd.Flags().Changed("max-capture") && cfg.Defaults.MaxCapture != "" {
+		f.maxCapture = cfg.Defaults.MaxCapture
+	}
+	if !cmd.Flags().Changed("retries") && cfg.Defaults.Retries > 0 {
+		f.retries = cfg.Defaults.Retries
+	}
+	if !cmd.Flags().Changed("retry-delay") && cfg.Defaults.RetryDelay > 0 {
+		f.retryDelay = cfg.Defaults.RetryDelay
+	}
+	if !cmd.Flags().Changed("retry-on") && cfg.Defaults.RetryOn != "" {
+		f.retryOn = cfg.Defaults.RetryOn
+	}
+	if cfg.Defaults.Shell != "" {
+		f.shell = cfg.Defaults.Shell
+	}
+	f.defaultEnv = envPairs(cfg.Defaults.Env)
 	return f
 }
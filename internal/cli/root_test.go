@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/alfranz/hush/internal/runner"
+)
+
+func TestJoinArgsRunsVerbatim(t *testing.T) {
+	command := joinArgs([]string{"printf", "%s|", "it's", "&&", "$HOME", ""})
+	if command != `printf '%s|' 'it'\''s' '&&' '$HOME' ''` {
+		t.Errorf("unexpected command line: %s", command)
+	}
+
+	result, err := runCommand(t.Context(), command, sharedFlags{shell: runner.ShellNone}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result.Output) != "it's|&&|$HOME||" {
+		t.Errorf("expected arguments passed verbatim, got %q", result.Output)
+	}
+}
+
+func TestBrokenConfigOnlyFailsCommandsThatReadIt(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/.hush.yaml", []byte("checks:\n  test:\n    cmd: go test\n    needs: [build]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	cmd := newCommand()
+	cmd.SetArgs([]string{"batch", "true"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "invalid .hush.yaml") {
+		t.Errorf("expected a config error from batch, got %v", err)
+	}
+
+	initCmd, _, err := newCommand().Find([]string{"init"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if readsConfig(initCmd) {
+		t.Error("expected init to run despite a broken config")
+	}
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"slices"
+
+	"github.com/alfranz/hush/internal/config"
+	"github.com/alfranz/hush/internal/output"
+	"github.com/alfranz/hush/internal/runner"
+	"golang.org/x/term"
+)
+
+// runCommand runs command with the settings in f, retrying it as set by
+// --retries. With --live, progress is
+// shown on stderr while it runs: a rolling tail on a terminal when rolling
+// is set, heartbeat lines otherwise.
+func runCommand(ctx context.Context, command string, f sharedFlags, rolling bool) (*runner.Result, error) {
+	opts := runner.Options{
+		Command:    command,
+		Label:      f.label,
+		Shell:      f.shell,
+		Dir:        f.dir,
+		Env:        slices.Concat(f.defaultEnv, f.env),
+		Timeout:    f.timeout,
+		Retries:    f.retries,
+		RetryDelay: f.retryDelay,
+		RetryOn:    f.retryOn,
+	}
+	if f.envFile != "" {
+		vars, err := config.ReadEnvFile(f.envFile)
+		if err != nil {
+			return nil, err
+		}
+		opts.Env = slices.Concat(f.defaultEnv, vars, f.env)
+	}
+	// validate has already rejected malformed sizes
+	opts.MaxCapture, _ = parseSize(f.maxCapture)
+	if f.live {
+		label := f.label
+		if label == "" {
+			label = runner.DeriveLabel(command)
+		}
+		live := output.NewLive(os.Stderr, label, rolling && isTerminal(os.Stderr))
+		defer live.Stop()
+		opts.OnLine = live.Line
+	}
+	return runner.Run(ctx, opts)
+}
+
+// isTerminal reports whether f is a terminal.
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
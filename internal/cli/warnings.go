@@ -1,24 +1,39 @@
 package cli
 
-import "github.com/alfranz/hush/internal/filter"
+import (
+	"os"
+
+	"github.com/alfranz/hush/internal/filter"
+)
 
 type warningReport struct {
 	count int
 	lines []byte
 }
 
-func buildWarningReport(raw []byte, f sharedFlags) warningReport {
+// buildWarningReport finds the warnings in raw, or in the complete output
+// in the spill file if raw is incomplete.
+func buildWarningReport(raw []byte, spill string, f sharedFlags) warningReport {
 	if f.warnPattern == "" {
 		return warningReport{}
 	}
 
-	cleaned := filter.Apply(raw, filter.Options{StripANSI: true})
-	matches := filter.MatchLines(cleaned, f.warnPattern)
+	var matches filter.MatchResult
+	scanned := false
+	if spill != "" {
+		var err error
+		matches, err = matchFile(spill, f.warnPattern)
+		scanned = err == nil
+	}
+	if !scanned {
+		cleaned, _ := filter.Apply(raw, filter.Options{StripANSI: true})
+		matches = filter.MatchLines(cleaned, f.warnPattern)
+	}
 	if matches.Count == 0 {
 		return warningReport{}
 	}
 
-	lines := filter.Apply(matches.Lines, filter.Options{
+	lines, _ := filter.Apply(matches.Lines, filter.Options{
 		Head: f.head,
 		Tail: f.tail,
 		Grep: f.grep,
@@ -28,10 +43,19 @@ func buildWarningReport(raw []byte, f sharedFlags) warningReport {
 	if warnTail <= 0 {
 		warnTail = 10
 	}
-	lines = filter.Apply(lines, filter.Options{Tail: warnTail})
+	lines, _ = filter.Apply(lines, filter.Options{Tail: warnTail})
 
 	return warningReport{
 		count: matches.Count,
 		lines: lines,
 	}
 }
+
+func matchFile(path, pattern string) (filter.MatchResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return filter.MatchResult{}, err
+	}
+	defer file.Close()
+	return filter.MatchReader(file, pattern)
+}
@@ -6,7 +6,7 @@ import (
 )
 
 func TestBuildWarningReportNoPattern(t *testing.T) {
-	report := buildWarningReport([]byte("warning TS1000\n"), sharedFlags{})
+	report := buildWarningReport([]byte("warning TS1000\n"), "", sharedFlags{})
 	if report.count != 0 {
 		t.Fatalf("expected 0 warnings, got %d", report.count)
 	}
@@ -18,7 +18,7 @@ func TestBuildWarningReportDefaultTail(t *testing.T) {
 		sb.WriteString("warning TS1000\n")
 	}
 
-	report := buildWarningReport([]byte(sb.String()), sharedFlags{warnPattern: `warning TS[0-9]+`})
+	report := buildWarningReport([]byte(sb.String()), "", sharedFlags{warnPattern: `warning TS[0-9]+`})
 	if report.count != 12 {
 		t.Fatalf("expected 12 warnings, got %d", report.count)
 	}
@@ -30,7 +30,7 @@ func TestBuildWarningReportDefaultTail(t *testing.T) {
 
 func TestBuildWarningReportRespectsFilterFlags(t *testing.T) {
 	input := []byte("warning TS1000\nwarning TS2000\nwarning TS3000\n")
-	report := buildWarningReport(input, sharedFlags{
+	report := buildWarningReport(input, "", sharedFlags{
 		warnPattern: `warning TS[0-9]+`,
 		warnTail:    5,
 		head:        2,
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alfranz/hush/internal/config"
+	"github.com/alfranz/hush/internal/output"
+	"github.com/alfranz/hush/internal/watch"
+	"github.com/spf13/cobra"
+)
+
+var watchDebounce time.Duration
+
+func newWatchCmd(cfg *config.Config, names []string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch [check...]",
+		Short: "Rerun checks from .hush.yaml when files change",
+		Long: "Runs the checks, then reruns those affected whenever files in the project change, skipping\n" +
+			"files ignored by .gitignore. A check reruns on changes matching its watch: globs, or without\n" +
+			"globs on any change below its dir. Only changes of status are printed, e.g. ✗ → ✓ lint.",
+		Args:          cobra.OnlyValidArgs,
+		ValidArgs:     names,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWatch(cfg, args)
+		},
+	}
+	cmd.Flags().DurationVar(&watchDebounce, "debounce", 300*time.Millisecond, "Wait until files have not changed for this long before rerunning")
+	return cmd
+}
+
+func runWatch(cfg *config.Config, args []string) error {
+	names := cfg.CheckNames()
+	if len(args) > 0 {
+		names = cfg.WithNeeds(args...)
+	}
+	for _, j := range checkJobs(cfg, names) {
+		if err := j.flags.validate(); err != nil {
+			return err
+		}
+	}
+	openRuns(cfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	w, err := watch.New(cfg.Dir, watchDebounce)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	out := output.NewTransitionFormatter(os.Stdout, output.FormatOptions{
+		Durations: checkFlags(config.Check{}, cfg).durations,
+	})
+	seen := make(contentSeen)
+	run := func(names []string) {
+		jobs := checkJobs(cfg, names)
+		for i := range jobs {
+			jobs[i].flags.live = false
+		}
+		start := time.Now()
+		summary, err := runJobs(ctx, out, jobs, batchOptions{
+			continueOnError: true,
+			parallel:        cfg.Defaults.Parallel,
+		})
+		elapsed := time.Since(start)
+		if err != nil {
+			if ctx.Err() == nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			return
+		}
+		out.Summary(output.Summary{Passed: summary.passed, Total: len(jobs), Duration: elapsed})
+	}
+
+	run(names)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case changes := <-w.C:
+			// Changes made while checks ran count too. Files rewritten
+			// with the same content are skipped, so that checks writing
+			// into the project settle instead of retriggering themselves.
+			var paths []string
+			for _, c := range changes {
+				if seen.changed(cfg.Path(c.Path)) {
+					paths = append(paths, c.Path)
+				}
+			}
+			if affected := affectedChecks(cfg, names, paths); len(affected) > 0 {
+				run(affected)
+			}
+		}
+	}
+}
+
+// contentSeen holds the content hash of each changed file when it was last
+// seen; a missing file has the zero hash.
+type contentSeen map[string][sha256.Size]byte
+
+// changed records the content of the file at path, reporting whether it
+// differs from when the file was last seen.
+func (s contentSeen) changed(path string) bool {
+	var sum [sha256.Size]byte
+	if data, err := os.ReadFile(path); err == nil {
+		sum = sha256.Sum256(data)
+	}
+	old, ok := s[path]
+	s[path] = sum
+	return !ok || old != sum
+}
+
+// affectedChecks returns the checks among names that a change to paths
+// reruns, together with the checks that need them, in the order of names.
+func affectedChecks(cfg *config.Config, names, paths []string) []string {
+	return withDependents(cfg, names, func(check config.Check) bool {
+		return watches(cfg, check, paths)
+	})
+}
+
+// watches reports whether a change to any of paths, relative to the config
+// file, reruns check: one matching its watch globs or, if it has none, one
+// below its dir.
+func watches(cfg *config.Config, check config.Check, paths []string) bool {
+	dir := "."
+	if check.Dir != "" {
+		if rel, err := filepath.Rel(cfg.Dir, cfg.Path(check.Dir)); err == nil {
+			dir = filepath.ToSlash(rel)
+		}
+	}
+	if len(check.Watch) > 0 {
+		return matchesAny(check.Watch, paths)
+	}
+	for _, p := range paths {
+		if dir == "." || p == dir || strings.HasPrefix(p, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
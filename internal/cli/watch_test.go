@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/alfranz/hush/internal/config"
+)
+
+func TestAffectedChecks(t *testing.T) {
+	cfg, err := config.Parse([]byte(`checks:
+  lint:
+    cmd: ruff check .
+    watch: ["*.py", "pyproject.toml"]
+  api:
+    cmd: go test ./...
+    dir: services/api
+  build:
+    cmd: make
+    watch: ["src/**"]
+  test:
+    cmd: make test
+    watch: ["tests/**"]
+    needs: [build]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Dir = "/repo"
+	names := cfg.CheckNames()
+
+	tests := []struct {
+		paths []string
+		want  []string
+	}{
+		{[]string{"app/models.py"}, []string{"lint"}},
+		{[]string{"services/api/main.go"}, []string{"api"}},
+		{[]string{"services/apiary/main.go"}, nil},
+		{[]string{"src/main.c"}, []string{"build", "test"}},
+		{[]string{"tests/test_main.c"}, []string{"test"}},
+		{[]string{"README.md"}, nil},
+	}
+	for _, tt := range tests {
+		if got := affectedChecks(cfg, names, tt.paths); !slices.Equal(got, tt.want) {
+			t.Errorf("affectedChecks(%q) = %q, want %q", tt.paths, got, tt.want)
+		}
+	}
+}
+
+func TestContentSeen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.py")
+	seen := make(contentSeen)
+
+	os.WriteFile(path, []byte("print(1)\n"), 0644)
+	if !seen.changed(path) {
+		t.Error("expected a first sighting to count as a change")
+	}
+	os.WriteFile(path, []byte("print(1)\n"), 0644)
+	if seen.changed(path) {
+		t.Error("expected a rewrite with the same content not to count")
+	}
+	os.WriteFile(path, []byte("print(2)\n"), 0644)
+	if !seen.changed(path) {
+		t.Error("expected new content to count")
+	}
+	os.Remove(path)
+	if !seen.changed(path) {
+		t.Error("expected a removal to count")
+	}
+}
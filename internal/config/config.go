@@ -2,34 +2,105 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
 )
 
 type Config struct {
 	Defaults Defaults         `yaml:"defaults" mapstructure:"defaults"`
 	Checks   map[string]Check `yaml:"checks" mapstructure:"checks"`
+	// Order overrides the run order of checks. Checks not listed run
+	// afterwards in the order they are declared.
+	Order []string `yaml:"order" mapstructure:"order"`
+	// Wrappers lists extra command prefixes, such as "dotenv --", that are
+	// skipped when deriving labels and detecting tools.
+	Wrappers []string `yaml:"wrappers" mapstructure:"wrappers"`
+
+	// Dir is the directory containing the loaded config file.
+	Dir string `yaml:"-" mapstructure:"-"`
+
+	// declared holds check names in document order.
+	declared []string
 }
 
 type Defaults struct {
-	Tail        int    `yaml:"tail" mapstructure:"tail"`
-	Head        int    `yaml:"head" mapstructure:"head"`
-	Grep        string `yaml:"grep" mapstructure:"grep"`
-	WarnPattern string `yaml:"warn-pattern" mapstructure:"warn-pattern"`
-	WarnTail    int    `yaml:"warn-tail" mapstructure:"warn-tail"`
-	Continue    bool   `yaml:"continue" mapstructure:"continue"`
+	Tail        int           `yaml:"tail" mapstructure:"tail"`
+	Head        int           `yaml:"head" mapstructure:"head"`
+	Grep        string        `yaml:"grep" mapstructure:"grep"`
+	WarnPattern string        `yaml:"warn-pattern" mapstructure:"warn-pattern"`
+	WarnTail    int           `yaml:"warn-tail" mapstructure:"warn-tail"`
+	Continue    bool          `yaml:"continue" mapstructure:"continue"`
+	Timeout     time.Duration `yaml:"timeout" mapstructure:"timeout"`
+	Parallel    int           `yaml:"parallel" mapstructure:"parallel"`
+	Durations   bool          `yaml:"durations" mapstructure:"durations"`
+	Slow        time.Duration `yaml:"slow" mapstructure:"slow"`
+	Parser      string        `yaml:"parser" mapstructure:"parser"`
+	MaxTokens   int           `yaml:"max-tokens" mapstructure:"max-tokens"`
+	GrepContext int           `yaml:"grep-context" mapstructure:"grep-context"`
+	GrepBefore  int           `yaml:"grep-before" mapstructure:"grep-before"`
+	GrepAfter   int           `yaml:"grep-after" mapstructure:"grep-after"`
+	Stream      string        `yaml:"stream" mapstructure:"stream"`
+	Live        bool          `yaml:"live" mapstructure:"live"`
+	MaxCapture  string        `yaml:"max-capture" mapstructure:"max-capture"`
+	Retries     int           `yaml:"retries" mapstructure:"retries"`
+	RetryDelay  time.Duration `yaml:"retry-delay" mapstructure:"retry-delay"`
+	RetryOn     string        `yaml:"retry-on" mapstructure:"retry-on"`
+	// Env sets environment variables for every command, beneath those a
+	// check sets itself or reads from its env file.
+	Env map[string]string `yaml:"env" mapstructure:"env"`
+	// Shell runs commands: sh (the default), bash, zsh, or none to run
+	// them without a shell.
+	Shell string `yaml:"shell" mapstructure:"shell"`
+	// KeepRuns is how many past runs to keep under .hush/runs.
+	KeepRuns int `yaml:"keep-runs" mapstructure:"keep-runs"`
 }
 
 type Check struct {
-	Cmd         string `yaml:"cmd" mapstructure:"cmd"`
-	Label       string `yaml:"label" mapstructure:"label"`
-	Grep        string `yaml:"grep" mapstructure:"grep"`
-	WarnPattern string `yaml:"warn-pattern" mapstructure:"warn-pattern"`
-	WarnTail    int    `yaml:"warn-tail" mapstructure:"warn-tail"`
-	Tail        int    `yaml:"tail" mapstructure:"tail"`
-	Head        int    `yaml:"head" mapstructure:"head"`
+	Cmd         string        `yaml:"cmd,omitempty" mapstructure:"cmd"`
+	Label       string        `yaml:"label,omitempty" mapstructure:"label"`
+	Grep        string        `yaml:"grep,omitempty" mapstructure:"grep"`
+	WarnPattern string        `yaml:"warn-pattern,omitempty" mapstructure:"warn-pattern"`
+	WarnTail    int           `yaml:"warn-tail,omitempty" mapstructure:"warn-tail"`
+	Tail        int           `yaml:"tail,omitempty" mapstructure:"tail"`
+	Head        int           `yaml:"head,omitempty" mapstructure:"head"`
+	Timeout     time.Duration `yaml:"timeout,omitempty" mapstructure:"timeout"`
+	Slow        time.Duration `yaml:"slow,omitempty" mapstructure:"slow"`
+	Parser      string        `yaml:"parser,omitempty" mapstructure:"parser"`
+	MaxTokens   int           `yaml:"max-tokens,omitempty" mapstructure:"max-tokens"`
+	GrepContext int           `yaml:"grep-context,omitempty" mapstructure:"grep-context"`
+	GrepBefore  int           `yaml:"grep-before,omitempty" mapstructure:"grep-before"`
+	GrepAfter   int           `yaml:"grep-after,omitempty" mapstructure:"grep-after"`
+	Stream      string        `yaml:"stream,omitempty" mapstructure:"stream"`
+	Retries     int           `yaml:"retries,omitempty" mapstructure:"retries"`
+	RetryDelay  time.Duration `yaml:"retry-delay,omitempty" mapstructure:"retry-delay"`
+	RetryOn     string        `yaml:"retry-on,omitempty" mapstructure:"retry-on"`
+	Shell       string        `yaml:"shell,omitempty" mapstructure:"shell"`
+	// Dir is the working directory, relative to the config file.
+	Dir string `yaml:"dir,omitempty" mapstructure:"dir"`
+	// Env sets environment variables on top of those read from EnvFile, a
+	// dotenv file relative to the config file.
+	Env     map[string]string `yaml:"env,omitempty" mapstructure:"env"`
+	EnvFile string            `yaml:"env-file,omitempty" mapstructure:"env-file"`
+	// Watch lists globs, relative to the config file, of the files whose
+	// changes make hush watch rerun the check.
+	Watch []string `yaml:"watch,omitempty" mapstructure:"watch"`
+	// Inputs lists globs, relative to the config file, of the files the
+	// check depends on. While they are unchanged since it last passed,
+	// the check is not run again.
+	Inputs []string `yaml:"inputs,omitempty" mapstructure:"inputs"`
+	// Paths lists globs, relative to the config file, of the files the
+	// check is about. hush all --changed runs it only if one of them
+	// changed; a check without paths always runs.
+	Paths []string `yaml:"paths,omitempty" mapstructure:"paths"`
+	// Needs lists checks that must pass before this one runs.
+	Needs []string `yaml:"needs,omitempty" mapstructure:"needs"`
 }
 
 func Load() (*Config, error) {
@@ -59,9 +130,147 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	data, err := os.ReadFile(v.ConfigFileUsed())
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Dir = filepath.Dir(v.ConfigFileUsed())
+	return cfg, nil
+}
+
+// Parse decodes a .hush.yaml document. It decodes the YAML node tree
+// directly rather than going through viper so that check names keep their
+// case and declaration order.
+func Parse(data []byte) (*Config, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
 	var cfg Config
-	if err := v.Unmarshal(&cfg); err != nil {
+	if len(root.Content) == 0 {
+		return &cfg, nil
+	}
+	doc := root.Content[0]
+	if err := doc.Decode(&cfg); err != nil {
+		return nil, err
+	}
+
+	if checks := mappingValue(doc, "checks"); checks != nil && checks.Kind == yaml.MappingNode {
+		for i := 0; i < len(checks.Content); i += 2 {
+			cfg.declared = append(cfg.declared, checks.Content[i].Value)
+		}
+	}
+
+	for _, name := range cfg.Order {
+		if _, ok := cfg.Checks[name]; !ok {
+			return nil, fmt.Errorf("order: unknown check %q", name)
+		}
+	}
+	if err := cfg.validateNeeds(); err != nil {
 		return nil, err
 	}
 	return &cfg, nil
 }
+
+// Path resolves a path from the config file against the directory the
+// file is in.
+func (c *Config) Path(p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(c.Dir, p)
+}
+
+// CheckNames returns check names in run order: the explicit order list
+// first, then the remaining checks in declaration order. A check is always
+// placed after the checks it needs.
+func (c *Config) CheckNames() []string {
+	var preferred []string
+	for _, name := range append(append([]string{}, c.Order...), c.declared...) {
+		if _, ok := c.Checks[name]; ok {
+			preferred = append(preferred, name)
+		}
+	}
+	return c.WithNeeds(preferred...)
+}
+
+// WithNeeds returns names together with every check they transitively need,
+// ordered so that each check comes after its dependencies.
+func (c *Config) WithNeeds(names ...string) []string {
+	visited := make(map[string]bool, len(c.Checks))
+	var ordered []string
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		for _, dep := range c.Checks[name].Needs {
+			visit(dep)
+		}
+		ordered = append(ordered, name)
+	}
+	for _, name := range names {
+		visit(name)
+	}
+	return ordered
+}
+
+// validateNeeds rejects references to unknown checks and dependency cycles.
+func (c *Config) validateNeeds() error {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(c.Checks))
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			start := slices.Index(path, name)
+			cycle := append(slices.Clone(path[start:]), name)
+			return fmt.Errorf("checks: dependency cycle: %s", strings.Join(cycle, " -> "))
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range c.Checks[name].Needs {
+			if _, ok := c.Checks[dep]; !ok {
+				return fmt.Errorf("checks.%s.needs: unknown check %q", name, dep)
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		return nil
+	}
+
+	for _, name := range c.declared {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
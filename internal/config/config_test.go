@@ -3,7 +3,9 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadNoConfig(t *testing.T) {
@@ -73,6 +75,7 @@ func TestLoadDefaults(t *testing.T) {
   warn-pattern: "warning TS[0-9]+"
   warn-tail: 5
   continue: true
+  parallel: 4
 
 checks:
   test:
@@ -108,6 +111,9 @@ checks:
 	if !cfg.Defaults.Continue {
 		t.Error("expected defaults.continue true")
 	}
+	if cfg.Defaults.Parallel != 4 {
+		t.Errorf("expected defaults.parallel 4, got %d", cfg.Defaults.Parallel)
+	}
 	if cfg.Checks["test"].WarnPattern != "deprecated" {
 		t.Errorf("expected check warn-pattern, got %q", cfg.Checks["test"].WarnPattern)
 	}
@@ -151,3 +157,206 @@ checks:
 		t.Error("expected defaults.continue false (unset)")
 	}
 }
+
+func TestLoadTimeout(t *testing.T) {
+	tmp := t.TempDir()
+	orig, _ := os.Getwd()
+	defer os.Chdir(orig)
+
+	content := []byte(`defaults:
+  timeout: 5m
+
+checks:
+  test:
+    cmd: pytest -x
+    timeout: 90s
+`)
+	os.WriteFile(filepath.Join(tmp, ".hush.yaml"), content, 0644)
+	os.Chdir(tmp)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Defaults.Timeout != 5*time.Minute {
+		t.Errorf("expected defaults.timeout 5m, got %s", cfg.Defaults.Timeout)
+	}
+	if cfg.Checks["test"].Timeout != 90*time.Second {
+		t.Errorf("expected check timeout 90s, got %s", cfg.Checks["test"].Timeout)
+	}
+}
+
+func TestCheckNamesDeclarationOrder(t *testing.T) {
+	cfg, err := Parse([]byte(`checks:
+  format:
+    cmd: ruff format --check .
+  lint:
+    cmd: ruff check .
+  test:
+    cmd: pytest -x
+  build:
+    cmd: make
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := strings.Join(cfg.CheckNames(), ",")
+	if got != "format,lint,test,build" {
+		t.Errorf("expected declaration order, got %q", got)
+	}
+}
+
+func TestCheckNamesExplicitOrder(t *testing.T) {
+	cfg, err := Parse([]byte(`order: [test, lint]
+checks:
+  format:
+    cmd: ruff format --check .
+  lint:
+    cmd: ruff check .
+  test:
+    cmd: pytest -x
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := strings.Join(cfg.CheckNames(), ",")
+	if got != "test,lint,format" {
+		t.Errorf("expected explicit order first, got %q", got)
+	}
+}
+
+func TestParseOrderUnknownCheck(t *testing.T) {
+	_, err := Parse([]byte(`order: [missing]
+checks:
+  lint:
+    cmd: ruff check .
+`))
+	if err == nil {
+		t.Fatal("expected error for unknown check in order")
+	}
+}
+
+func TestParseKeepsCheckNameCase(t *testing.T) {
+	cfg, err := Parse([]byte(`checks:
+  typeCheck:
+    cmd: tsc --noEmit
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := cfg.Checks["typeCheck"]; !ok {
+		t.Errorf("expected check name case preserved, got %v", cfg.CheckNames())
+	}
+}
+
+func TestCheckNamesRespectsNeeds(t *testing.T) {
+	cfg, err := Parse([]byte(`checks:
+  test:
+    cmd: pytest -x
+    needs: [build]
+  lint:
+    cmd: ruff check .
+  build:
+    cmd: make
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(cfg.CheckNames(), ","); got != "build,test,lint" {
+		t.Errorf("expected build before test, got %q", got)
+	}
+	if got := strings.Join(cfg.WithNeeds("test"), ","); got != "build,test" {
+		t.Errorf("expected test with its needs, got %q", got)
+	}
+}
+
+func TestParseNeedsUnknownCheck(t *testing.T) {
+	_, err := Parse([]byte(`checks:
+  test:
+    cmd: pytest -x
+    needs: [build]
+`))
+	if err == nil || !strings.Contains(err.Error(), `unknown check "build"`) {
+		t.Fatalf("expected unknown check error, got %v", err)
+	}
+}
+
+func TestParseNeedsCycle(t *testing.T) {
+	_, err := Parse([]byte(`checks:
+  a:
+    cmd: "true"
+    needs: [b]
+  b:
+    cmd: "true"
+    needs: [c]
+  c:
+    cmd: "true"
+    needs: [a]
+`))
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle: a -> b -> c -> a") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+}
+
+func TestLoadRecordsDir(t *testing.T) {
+	tmp, _ := filepath.EvalSymlinks(t.TempDir())
+	orig, _ := os.Getwd()
+	defer os.Chdir(orig)
+
+	os.WriteFile(filepath.Join(tmp, ".hush.yaml"), []byte("defaults:\n  keep-runs: 5\n"), 0644)
+	sub := filepath.Join(tmp, "src")
+	os.Mkdir(sub, 0755)
+	os.Chdir(sub)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Dir != tmp {
+		t.Errorf("expected dir %q, got %q", tmp, cfg.Dir)
+	}
+	if cfg.Defaults.KeepRuns != 5 {
+		t.Errorf("expected keep-runs 5, got %d", cfg.Defaults.KeepRuns)
+	}
+}
+
+func TestParseDirAndEnv(t *testing.T) {
+	cfg, err := Parse([]byte(`defaults:
+  env:
+    CI: "1"
+checks:
+  api:
+    cmd: go test ./...
+    dir: services/api
+    env-file: .env.test
+    env:
+      GOFLAGS: -count=1
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg.Dir = "/repo"
+	api := cfg.Checks["api"]
+	if got := cfg.Path(api.Dir); got != filepath.Join("/repo", "services", "api") {
+		t.Errorf("unexpected dir: %q", got)
+	}
+	if got := cfg.Path(api.EnvFile); got != filepath.Join("/repo", ".env.test") {
+		t.Errorf("unexpected env-file: %q", got)
+	}
+	if cfg.Path("/abs") != "/abs" || cfg.Path("") != "" {
+		t.Error("expected absolute and empty paths to be kept")
+	}
+	if api.Env["GOFLAGS"] != "-count=1" || cfg.Defaults.Env["CI"] != "1" {
+		t.Errorf("unexpected env: %v, defaults %v", api.Env, cfg.Defaults.Env)
+	}
+}
+
+func TestParseWrappers(t *testing.T) {
+	cfg, err := Parse([]byte("wrappers:\n  - dotenv --\n  - with-secrets\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Wrappers) != 2 || cfg.Wrappers[0] != "dotenv --" {
+		t.Errorf("unexpected wrappers: %q", cfg.Wrappers)
+	}
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReadEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := `# database
+DATABASE_URL=postgres://localhost/test
+export API_KEY = secret
+GREETING="hello world"
+QUOTED='a # b'
+DEBUG=1 # verbose
+EMPTY=
+`
+	os.WriteFile(path, []byte(content), 0644)
+
+	env, err := ReadEnvFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		"DATABASE_URL=postgres://localhost/test",
+		"API_KEY=secret",
+		"GREETING=hello world",
+		"QUOTED=a # b",
+		"DEBUG=1",
+		"EMPTY=",
+	}
+	if !slices.Equal(env, want) {
+		t.Errorf("unexpected env:\n got: %q\nwant: %q", env, want)
+	}
+}
+
+func TestReadEnvFileInvalidLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	os.WriteFile(path, []byte("A=1\nnot a pair\n"), 0644)
+
+	_, err := ReadEnvFile(path)
+	if err == nil || err.Error() != path+":2: expected KEY=VALUE" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
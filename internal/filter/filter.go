@@ -2,14 +2,101 @@ package filter
 
 import (
 	"bytes"
+	"fmt"
 	"regexp"
+	"slices"
 )
 
 type Options struct {
-	Head      int
-	Tail      int
-	Grep      string
-	StripANSI bool
+	// Lines keeps only this range of lines, before any other filtering.
+	// A zero End means through the last line.
+	Lines Range
+	Head  int
+	Tail  int
+	Grep  string
+	// GrepBefore and GrepAfter keep this many lines of context around
+	// each grep match, like grep -B and -A.
+	GrepBefore int
+	GrepAfter  int
+	StripANSI  bool
+	// Parser names a tool-aware extractor (see ParserNames) applied before
+	// grep and head/tail.
+	Parser string
+	// MaxTokens trims the output to fit this token budget, applied last.
+	MaxTokens int
+	// Tokens counts tokens for MaxTokens; EstimateTokens if nil.
+	Tokens TokenCounter
+}
+
+// Stats describes what filtering removed from the output.
+type Stats struct {
+	// TotalLines is the number of lines in the unfiltered output.
+	TotalLines int
+	// KeptLines is the number of original lines that survived, not
+	// counting markers inserted in their place.
+	KeptLines int
+	// Elided lists the removed line ranges.
+	Elided []Range
+	// Marked is how many of the removed lines are counted by markers in
+	// the output, such as "… 12 lines omitted …".
+	Marked int
+}
+
+// Omitted returns how many lines filtering removed.
+func (s Stats) Omitted() int {
+	return s.TotalLines - s.KeptLines
+}
+
+// WithGap adjusts s for output in which the given 1-based line was a
+// marker standing in for n lines that were never captured.
+func (s Stats) WithGap(marker, n int) Stats {
+	if marker <= 0 || marker > s.TotalLines {
+		return s
+	}
+	shift := n - 1
+	s.TotalLines += shift
+	var ranges []Range
+	covered := false
+	for _, r := range s.Elided {
+		switch {
+		case r.End < marker:
+		case r.Start > marker:
+			r.Start += shift
+			r.End += shift
+		default:
+			r.End += shift
+			covered = true
+		}
+		ranges = append(ranges, r)
+	}
+	if !covered {
+		// The marker was shown: the lines it stands for are still missing.
+		s.KeptLines--
+		s.Marked += n
+		ranges = append(ranges, Range{Start: marker, End: marker + shift})
+		slices.SortFunc(ranges, func(a, b Range) int { return a.Start - b.Start })
+	}
+	s.Elided = mergeRanges(ranges)
+	return s
+}
+
+// mergeRanges joins sorted ranges that touch.
+func mergeRanges(ranges []Range) []Range {
+	var out []Range
+	for _, r := range ranges {
+		if n := len(out); n > 0 && out[n-1].End+1 >= r.Start {
+			out[n-1].End = max(out[n-1].End, r.End)
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// Range is an inclusive, 1-based span of output lines.
+type Range struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
 }
 
 type MatchResult struct {
@@ -17,7 +104,21 @@ type MatchResult struct {
 	Count int
 }
 
-func Apply(raw []byte, opts Options) []byte {
+// line is an output line tagged with its 0-based position in the
+// unfiltered output; markers inserted by filtering have n == -1, and omits
+// set to the number of lines their text says were left out.
+type line struct {
+	n     int
+	text  []byte
+	omits int
+}
+
+func marker(text string) line {
+	return line{n: -1, text: []byte(text)}
+}
+
+// Apply filters raw according to opts and reports which lines it removed.
+func Apply(raw []byte, opts Options) ([]byte, Stats) {
 	result := raw
 
 	// 1. ANSI stripping
@@ -25,47 +126,150 @@ func Apply(raw []byte, opts Options) []byte {
 		result = StripANSI(result)
 	}
 
-	// 2. Grep filter
+	lines := splitTagged(result)
+	stats := Stats{TotalLines: len(lines), KeptLines: len(lines)}
+	if opts.Lines == (Range{}) && opts.Parser == "" && opts.Grep == "" && opts.Head <= 0 && opts.Tail <= 0 && opts.MaxTokens <= 0 {
+		return result, stats
+	}
+
+	// 2. Line range
+	if opts.Lines != (Range{}) {
+		lines = applyLineRange(lines, opts.Lines)
+	}
+
+	// 3. Tool-aware extraction
+	if opts.Parser != "" {
+		lines = applyParser(lines, opts.Parser)
+	}
+
+	// 4. Grep filter
 	if opts.Grep != "" {
-		result = applyGrep(result, opts.Grep)
+		lines = applyGrep(lines, opts.Grep, opts.GrepBefore, opts.GrepAfter)
 	}
 
-	// 3. Head/Tail
+	// 5. Head/Tail
 	if opts.Head > 0 || opts.Tail > 0 {
-		result = applyHeadTail(result, opts.Head, opts.Tail)
+		lines = applyHeadTail(lines, opts.Head, opts.Tail)
+	}
+
+	// 6. Token budget
+	if opts.MaxTokens > 0 {
+		lines = applyTokenBudget(lines, opts.MaxTokens, opts.Tokens)
+	}
+
+	stats.KeptLines, stats.Elided, stats.Marked = elided(lines, stats.TotalLines)
+	if unchanged(lines, stats.TotalLines) {
+		return result, stats
+	}
+	return joinTagged(lines), stats
+}
+
+func applyLineRange(lines []line, r Range) []line {
+	start, end := max(r.Start-1, 0), len(lines)
+	if r.End > 0 {
+		end = min(r.End, end)
+	}
+	if start >= end {
+		return nil
+	}
+	return lines[start:end]
+}
+
+// applyGrep keeps matching lines plus before/after lines of context.
+func applyGrep(lines []line, pattern string, before, after int) []line {
+	g, err := newGrepper(pattern, before, after)
+	if err != nil {
+		return lines // invalid pattern, return unfiltered
+	}
+	for _, l := range lines {
+		g.line(l)
 	}
+	return g.kept
+}
 
-	return result
+// grepper is the grep stage run one line at a time. Overlapping context
+// windows are merged and separate groups are divided by "--", as GNU grep
+// does.
+type grepper struct {
+	re            *regexp.Regexp
+	before, after int
+	// recent holds up to before unkept lines preceding the current one.
+	recent    []line
+	afterLeft int
+	// last is the position of the last kept line, or -1.
+	last int
+	kept []line
 }
 
-func applyGrep(b []byte, pattern string) []byte {
+func newGrepper(pattern string, before, after int) (*grepper, error) {
 	re, err := regexp.Compile(pattern)
 	if err != nil {
-		return b // invalid pattern, return unfiltered
+		return nil, err
 	}
-	var matched [][]byte
-	for line := range bytes.SplitSeq(b, []byte("\n")) {
-		if re.Match(line) {
-			matched = append(matched, line)
+	return &grepper{re: re, before: before, after: after, last: -1}, nil
+}
+
+func (g *grepper) line(l line) {
+	switch {
+	case g.re.Match(l.text):
+		first := l
+		if len(g.recent) > 0 {
+			first = g.recent[0]
+		}
+		if (g.before > 0 || g.after > 0) && g.last >= 0 && first.n > g.last+1 {
+			g.kept = append(g.kept, marker("--"))
+		}
+		g.kept = append(append(g.kept, g.recent...), l)
+		g.recent = g.recent[:0]
+		g.afterLeft = g.after
+		g.last = l.n
+	case g.afterLeft > 0:
+		g.kept = append(g.kept, l)
+		g.afterLeft--
+		g.last = l.n
+	case g.before > 0:
+		if len(g.recent) == g.before {
+			g.recent = append(g.recent[:0], g.recent[1:]...)
 		}
+		g.recent = append(g.recent, l)
 	}
-	return bytes.Join(matched, []byte("\n"))
 }
 
-func applyHeadTail(b []byte, head, tail int) []byte {
-	lines := bytes.Split(b, []byte("\n"))
-	// Remove trailing empty line from split
-	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
-		lines = lines[:len(lines)-1]
+// applyHeadTail keeps the first head and last tail lines. With both set,
+// the gap between them is replaced by a marker.
+func applyHeadTail(lines []line, head, tail int) []line {
+	switch {
+	case head > 0 && tail > 0:
+		if head+tail >= len(lines) {
+			return lines
+		}
+		kept := append([]line{}, lines[:head]...)
+		kept = append(kept, headTailMarker(omittedLines(lines[head:len(lines)-tail])))
+		return append(kept, lines[len(lines)-tail:]...)
+	case tail > 0 && tail < len(lines):
+		return lines[len(lines)-tail:]
+	case head > 0 && head < len(lines):
+		return lines[:head]
 	}
+	return lines
+}
 
-	if tail > 0 && tail < len(lines) {
-		lines = lines[len(lines)-tail:]
-	}
-	if head > 0 && head < len(lines) {
-		lines = lines[:head]
+func headTailMarker(omitted int) line {
+	return line{n: -1, text: fmt.Appendf(nil, "… %s lines omitted …", GroupThousands(omitted)), omits: omitted}
+}
+
+// omittedLines returns how many lines of the unfiltered output lines stand
+// for: its own original lines plus those counted by its markers.
+func omittedLines(lines []line) int {
+	n := 0
+	for _, l := range lines {
+		if l.n >= 0 {
+			n++
+		} else {
+			n += l.omits
+		}
 	}
-	return bytes.Join(lines, []byte("\n"))
+	return n
 }
 
 func MatchLines(b []byte, pattern string) MatchResult {
@@ -88,3 +292,61 @@ func MatchLines(b []byte, pattern string) MatchResult {
 		Count: count,
 	}
 }
+
+// splitTagged splits b into lines, ignoring the final newline.
+func splitTagged(b []byte) []line {
+	b = bytes.TrimSuffix(b, []byte("\n"))
+	if len(b) == 0 {
+		return nil
+	}
+	parts := bytes.Split(b, []byte("\n"))
+	lines := make([]line, len(parts))
+	for i, p := range parts {
+		lines[i] = line{n: i, text: p}
+	}
+	return lines
+}
+
+func joinTagged(lines []line) []byte {
+	texts := make([][]byte, len(lines))
+	for i, l := range lines {
+		texts[i] = l.text
+	}
+	return bytes.Join(texts, []byte("\n"))
+}
+
+// unchanged reports whether lines is still the full, unmodified output.
+func unchanged(lines []line, total int) bool {
+	if len(lines) != total {
+		return false
+	}
+	for i, l := range lines {
+		if l.n != i {
+			return false
+		}
+	}
+	return true
+}
+
+// elided counts the original lines in kept and returns the ranges of
+// original lines that are missing, and how many of them markers count. Every stage keeps lines in order, so
+// kept is sorted by position.
+func elided(kept []line, total int) (int, []Range, int) {
+	var ranges []Range
+	count, next, marked := 0, 0, 0
+	for _, l := range kept {
+		marked += l.omits
+		if l.n < next {
+			continue // marker, or a line kept twice
+		}
+		if l.n > next {
+			ranges = append(ranges, Range{Start: next + 1, End: l.n})
+		}
+		count++
+		next = l.n + 1
+	}
+	if next < total {
+		ranges = append(ranges, Range{Start: next + 1, End: total})
+	}
+	return count, ranges, marked
+}
@@ -1,13 +1,19 @@
 package filter
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 )
 
+func apply(b []byte, opts Options) string {
+	out, _ := Apply(b, opts)
+	return string(out)
+}
+
 func TestApplyHead(t *testing.T) {
 	input := "line1\nline2\nline3\nline4\nline5\n"
-	got := string(Apply([]byte(input), Options{Head: 3}))
+	got := apply([]byte(input), Options{Head: 3})
 	if got != "line1\nline2\nline3" {
 		t.Errorf("expected first 3 lines, got: %q", got)
 	}
@@ -15,7 +21,7 @@ func TestApplyHead(t *testing.T) {
 
 func TestApplyTail(t *testing.T) {
 	input := "line1\nline2\nline3\nline4\nline5\n"
-	got := string(Apply([]byte(input), Options{Tail: 2}))
+	got := apply([]byte(input), Options{Tail: 2})
 	if got != "line4\nline5" {
 		t.Errorf("expected last 2 lines, got: %q", got)
 	}
@@ -23,7 +29,7 @@ func TestApplyTail(t *testing.T) {
 
 func TestApplyGrep(t *testing.T) {
 	input := "INFO ok\nERROR bad\nINFO fine\nERROR worse\n"
-	got := string(Apply([]byte(input), Options{Grep: "ERROR"}))
+	got := apply([]byte(input), Options{Grep: "ERROR"})
 	if got != "ERROR bad\nERROR worse" {
 		t.Errorf("expected only ERROR lines, got: %q", got)
 	}
@@ -31,16 +37,61 @@ func TestApplyGrep(t *testing.T) {
 
 func TestApplyCombined(t *testing.T) {
 	input := "a\nb\nc\nd\ne\nf\ng\n"
-	got := string(Apply([]byte(input), Options{Tail: 5, Head: 3}))
-	// Head 3 of tail 5 (c,d,e,f,g) = c,d,e
-	if got != "c\nd\ne" {
-		t.Errorf("expected head 3 of tail 5, got: %q", got)
+	got := apply([]byte(input), Options{Head: 2, Tail: 2})
+	want := "a\nb\n… 3 lines omitted …\nf\ng"
+	if got != want {
+		t.Errorf("expected both ends with a gap marker, got: %q", got)
+	}
+}
+
+func TestApplyCombinedOverlap(t *testing.T) {
+	input := "a\nb\nc\nd\n"
+	got, stats := Apply([]byte(input), Options{Head: 3, Tail: 2})
+	if string(got) != input {
+		t.Errorf("expected all lines when head and tail overlap, got: %q", got)
+	}
+	if stats.Omitted() != 0 || stats.Elided != nil {
+		t.Errorf("expected nothing elided, got %+v", stats)
+	}
+}
+
+func TestApplyStats(t *testing.T) {
+	input := "a\nerror 1\nb\nc\nerror 2\nd\n"
+	_, stats := Apply([]byte(input), Options{Grep: "error"})
+	want := Stats{TotalLines: 6, KeptLines: 2, Elided: []Range{{1, 1}, {3, 4}, {6, 6}}}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("unexpected stats:\n got: %+v\nwant: %+v", stats, want)
+	}
+}
+
+func TestApplyStatsIgnoresMarkers(t *testing.T) {
+	input := "a\nb\nc\nd\ne\n"
+	_, stats := Apply([]byte(input), Options{Head: 1, Tail: 1})
+	if stats.KeptLines != 2 || stats.Omitted() != 3 {
+		t.Errorf("expected 2 kept and 3 omitted, got %+v", stats)
+	}
+	if want := []Range{{2, 4}}; !reflect.DeepEqual(stats.Elided, want) {
+		t.Errorf("expected elided %v, got %v", want, stats.Elided)
+	}
+	if stats.Marked != 3 {
+		t.Errorf("expected the marker to count 3 lines, got %d", stats.Marked)
+	}
+}
+
+func TestApplyMarkerCountsGrepGaps(t *testing.T) {
+	input := "error 1\na\nerror 2\nb\nc\nerror 3\nerror 4\n"
+	got, stats := Apply([]byte(input), Options{Grep: "error", Head: 1, Tail: 1})
+	if !strings.Contains(string(got), "… 2 lines omitted …") {
+		t.Errorf("expected the marker to count only original lines, got: %q", got)
+	}
+	if stats.Omitted() != 5 || stats.Marked != 2 {
+		t.Errorf("expected 5 omitted with 2 marked, got %+v", stats)
 	}
 }
 
 func TestApplyStripANSI(t *testing.T) {
 	input := "\x1b[31merror\x1b[0m\n"
-	got := string(Apply([]byte(input), Options{StripANSI: true}))
+	got := apply([]byte(input), Options{StripANSI: true})
 	if strings.Contains(got, "\x1b[") {
 		t.Error("expected ANSI codes stripped")
 	}
@@ -48,7 +99,7 @@ func TestApplyStripANSI(t *testing.T) {
 
 func TestApplyNoOp(t *testing.T) {
 	input := "hello world\n"
-	got := string(Apply([]byte(input), Options{}))
+	got := apply([]byte(input), Options{})
 	if got != input {
 		t.Errorf("expected passthrough, got: %q", got)
 	}
@@ -74,3 +125,44 @@ func TestMatchLinesInvalidPattern(t *testing.T) {
 		t.Fatalf("expected empty lines, got %q", string(got.Lines))
 	}
 }
+
+func TestApplyGrepContext(t *testing.T) {
+	input := "a\nerror: one\nb\nc\nd\ne\nerror: two\nf\n"
+	got := apply([]byte(input), Options{Grep: "error", GrepBefore: 1, GrepAfter: 1})
+	want := "a\nerror: one\nb\n--\ne\nerror: two\nf"
+	if got != want {
+		t.Errorf("unexpected output:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestApplyGrepContextMergesOverlap(t *testing.T) {
+	input := "x\nerror 1\ny\nerror 2\nz\nw\n"
+	got := apply([]byte(input), Options{Grep: "error", GrepAfter: 2})
+	want := "error 1\ny\nerror 2\nz\nw"
+	if got != want {
+		t.Errorf("unexpected output:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestApplyGrepContextAfterOnly(t *testing.T) {
+	input := "src/main.c:3: error: expected ';'\n    int x = 1\n             ^\nok\n"
+	got := apply([]byte(input), Options{Grep: "error", GrepAfter: 2})
+	want := "src/main.c:3: error: expected ';'\n    int x = 1\n             ^"
+	if got != want {
+		t.Errorf("unexpected output:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestApplyLines(t *testing.T) {
+	input := "a\nb\nc\nd\ne\n"
+	got, stats := Apply([]byte(input), Options{Lines: Range{Start: 2, End: 3}})
+	if string(got) != "b\nc" {
+		t.Errorf("expected lines 2-3, got: %q", got)
+	}
+	if want := []Range{{1, 1}, {4, 5}}; !reflect.DeepEqual(stats.Elided, want) {
+		t.Errorf("expected elided %v, got %v", want, stats.Elided)
+	}
+	if got := apply([]byte(input), Options{Lines: Range{Start: 4}}); got != "d\ne" {
+		t.Errorf("expected lines 4 onwards, got: %q", got)
+	}
+}
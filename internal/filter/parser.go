@@ -0,0 +1,315 @@
+package filter
+
+import (
+	"bytes"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Parser extracts the failure-relevant parts of a tool's output: failing
+// test blocks, assertion diffs and the short summary. It returns nil when
+// the output does not look like the tool's. Kept lines are returned as
+// indices into lines; -1 stands for an inserted blank line.
+type Parser func(lines []string) []int
+
+// parsers maps --parser names to extractors.
+var parsers = map[string]Parser{
+	"pytest": parsePytest,
+	"gotest": parseGoTest,
+	"jest":   parseJest,
+	"cargo":  parseCargo,
+	"tsc":    parseTSC,
+	"eslint": parseESLint,
+}
+
+// sniffers recognise a tool from its output, in priority order, for the
+// "auto" parser.
+var sniffers = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"pytest", regexp.MustCompile(`(?m)^=+ (test session starts|FAILURES|ERRORS|short test summary info) =+$`)},
+	{"gotest", regexp.MustCompile(`(?m)^(--- FAIL: |FAIL\t|ok  \t)`)},
+	{"jest", regexp.MustCompile(`(?m)^Test Suites: `)},
+	{"cargo", regexp.MustCompile(`(?m)^test result: |^error(\[E\d+\])?: `)},
+	{"tsc", regexp.MustCompile(`(?m)error TS\d+: `)},
+	{"eslint", regexp.MustCompile(`(?m)^✖ \d+ problems? `)},
+}
+
+// ParserNames returns the accepted --parser values. "auto" detects the
+// tool from its output; "none" disables extraction.
+func ParserNames() []string {
+	names := make([]string, 0, len(parsers)+2)
+	for name := range parsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append(names, "auto", "none")
+}
+
+// IsParser reports whether name is a known parser.
+func IsParser(name string) bool {
+	return name == "auto" || name == "none" || parsers[name] != nil
+}
+
+// DetectParser guesses the tool that produced b, returning "" if unknown.
+func DetectParser(b []byte) string {
+	for _, s := range sniffers {
+		if s.re.Match(b) {
+			return s.name
+		}
+	}
+	return ""
+}
+
+// applyParser runs the named extractor over lines. Unknown parsers and
+// output the extractor does not recognise are returned unchanged.
+func applyParser(lines []line, name string) []line {
+	texts := make([]string, len(lines))
+	for i, l := range lines {
+		texts[i] = string(bytes.TrimRight(l.text, "\r"))
+	}
+	if name == "auto" {
+		name = DetectParser([]byte(strings.Join(texts, "\n")))
+	}
+	parse := parsers[name]
+	if parse == nil {
+		return lines
+	}
+
+	kept := parse(texts)
+	if len(kept) == 0 {
+		return lines
+	}
+	out := make([]line, 0, len(kept))
+	for _, i := range tidyBlankLines(texts, kept) {
+		if i < 0 {
+			out = append(out, marker(""))
+			continue
+		}
+		text := texts[i]
+		if strings.TrimSpace(text) == "" {
+			text = ""
+		}
+		out = append(out, line{n: lines[i].n, text: []byte(text)})
+	}
+	return out
+}
+
+var pytestSection = regexp.MustCompile(`^=+ (.+?) =+$`)
+
+// parsePytest keeps the FAILURES and ERRORS tracebacks, the short test
+// summary and the final counts line.
+func parsePytest(lines []string) []int {
+	var kept []int
+	keep := false
+	for i, line := range lines {
+		if m := pytestSection.FindStringSubmatch(line); m != nil {
+			switch title := m[1]; {
+			case title == "FAILURES", title == "ERRORS", title == "short test summary info":
+				keep = true
+			case pytestCounts.MatchString(title):
+				kept = append(kept, i)
+				keep = false
+				continue
+			default:
+				keep = false
+			}
+		}
+		if keep {
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
+
+var pytestCounts = regexp.MustCompile(`\d+ (failed|passed|errors?|skipped|xfailed|xpassed)`)
+
+var (
+	goRun      = regexp.MustCompile(`^=== (RUN|PAUSE|CONT|NAME) `)
+	goPassSkip = regexp.MustCompile(`^(\s*)--- (PASS|SKIP): `)
+	goNoise    = regexp.MustCompile(`^(ok  \t|\?   \t|PASS$|coverage: )`)
+)
+
+// parseGoTest drops passing packages, passing tests and their logs, and
+// keeps failing tests, panics, build errors and FAIL lines.
+func parseGoTest(lines []string) []int {
+	var kept, pending []int
+	// skipIndent is the indentation of a --- PASS line whose nested
+	// output is being skipped, or -1.
+	skipIndent := -1
+	for i, line := range lines {
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if skipIndent >= 0 {
+			if indent > skipIndent && line != "" {
+				continue
+			}
+			skipIndent = -1
+		}
+		switch {
+		case goRun.MatchString(line):
+			// Output between RUN and the result line belongs to the test
+			pending = pending[:0]
+		case goPassSkip.MatchString(line):
+			pending = pending[:0]
+			skipIndent = indent
+		case goNoise.MatchString(line):
+		default:
+			pending = append(pending, i)
+			if strings.HasPrefix(strings.TrimSpace(line), "--- FAIL") || strings.HasPrefix(line, "FAIL") {
+				kept = append(kept, pending...)
+				pending = pending[:0]
+			}
+		}
+	}
+	return append(kept, pending...)
+}
+
+var (
+	jestFile    = regexp.MustCompile(`^\s*(PASS|FAIL)\s`)
+	jestTotals  = regexp.MustCompile(`^(Test Suites|Tests):\s`)
+	jestSummary = regexp.MustCompile(`^Summary of all failing tests`)
+	jestTable   = regexp.MustCompile(`^-+\|`)
+)
+
+// parseJest keeps FAIL suites with their ● blocks and the Test Suites /
+// Tests totals; passing suites, coverage tables and timing are dropped.
+func parseJest(lines []string) []int {
+	var kept []int
+	keep := false
+	for i, line := range lines {
+		switch {
+		case jestTotals.MatchString(line):
+			kept = append(kept, i)
+			keep = false
+		case jestSummary.MatchString(line):
+			keep = true
+		case jestTable.MatchString(line):
+			keep = false
+		case jestFile.MatchString(line):
+			keep = strings.Contains(line, "FAIL")
+			if keep {
+				kept = append(kept, i)
+			}
+		case keep:
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
+
+var (
+	cargoError   = regexp.MustCompile(`^error(\[E\d+\])?: `)
+	cargoResult  = regexp.MustCompile(`^test result: FAILED`)
+	cargoFailure = regexp.MustCompile(`^test .* \.\.\. FAILED$`)
+)
+
+// parseCargo keeps compiler errors, failing test names, the failures:
+// sections with panic messages, and FAILED result lines.
+func parseCargo(lines []string) []int {
+	var kept []int
+	inFailures, inError := false, false
+	for i, line := range lines {
+		switch {
+		case cargoResult.MatchString(line):
+			kept = append(kept, i)
+			inFailures = false
+		case line == "failures:":
+			inFailures = true
+			kept = append(kept, i)
+		case cargoError.MatchString(line):
+			inError = true
+			kept = append(kept, i)
+		case inError:
+			kept = append(kept, i)
+			if line == "" {
+				inError = false
+			}
+		case inFailures, cargoFailure.MatchString(line):
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
+
+var (
+	tscError   = regexp.MustCompile(`error TS\d+: `)
+	tscFound   = regexp.MustCompile(`^Found \d+ errors?`)
+	tscContext = regexp.MustCompile(`^(\s|\d+\s|$)`)
+)
+
+// parseTSC keeps error lines with their code frames and the "Found N
+// errors" summary.
+func parseTSC(lines []string) []int {
+	var kept []int
+	inError := false
+	for i, line := range lines {
+		switch {
+		case tscError.MatchString(line):
+			inError = true
+			kept = append(kept, i)
+		case tscFound.MatchString(line):
+			inError = false
+			kept = append(kept, i)
+		case inError && tscContext.MatchString(line):
+			kept = append(kept, i)
+		default:
+			inError = false
+		}
+	}
+	return kept
+}
+
+var (
+	eslintEntry   = regexp.MustCompile(`^\s+\d+:\d+\s+(error|warning)\s`)
+	eslintSummary = regexp.MustCompile(`^(✖ \d+ problems?|\s+\d+ errors? and \d+ warnings? potentially fixable)`)
+)
+
+// parseESLint keeps files with errors, their error entries (dropping
+// warnings) and the problem summary.
+func parseESLint(lines []string) []int {
+	var kept []int
+	file := -1
+	for i, line := range lines {
+		switch {
+		case eslintSummary.MatchString(line):
+			if strings.HasPrefix(line, "✖") {
+				kept = append(kept, -1)
+			}
+			kept = append(kept, i)
+		case eslintEntry.MatchString(line):
+			if !strings.Contains(line, " error ") {
+				continue
+			}
+			if file >= 0 {
+				kept = append(kept, -1, file)
+				file = -1
+			}
+			kept = append(kept, i)
+		case line != "" && line[0] != ' ':
+			file = i
+		}
+	}
+	return kept
+}
+
+// tidyBlankLines collapses runs of blank lines in kept and trims them from
+// both ends.
+func tidyBlankLines(lines []string, kept []int) []int {
+	blank := func(i int) bool {
+		return i < 0 || strings.TrimSpace(lines[i]) == ""
+	}
+	var out []int
+	for _, i := range kept {
+		if blank(i) && (len(out) == 0 || blank(out[len(out)-1])) {
+			continue
+		}
+		out = append(out, i)
+	}
+	for len(out) > 0 && blank(out[len(out)-1]) {
+		out = out[:len(out)-1]
+	}
+	return slices.Clip(out)
+}
@@ -0,0 +1,162 @@
+package filter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsersGolden(t *testing.T) {
+	tests := []struct {
+		fixture string
+		parser  string
+	}{
+		{"pytest_fail", "pytest"},
+		{"go_fail", "gotest"},
+		{"npm_fail", "jest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			input, err := os.ReadFile(filepath.Join("..", "..", "benchmark", "fixtures", tt.fixture+".txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(filepath.Join("testdata", tt.fixture+".golden"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := apply(input, Options{Parser: tt.parser}) + "\n"
+			if got != string(want) {
+				t.Errorf("%s parser output mismatch\n got:\n%s\nwant:\n%s", tt.parser, got, want)
+			}
+			if auto := apply(input, Options{Parser: "auto"}) + "\n"; auto != got {
+				t.Errorf("auto parser differs from %s", tt.parser)
+			}
+		})
+	}
+}
+
+func TestParseGoTestVerbose(t *testing.T) {
+	input := `=== RUN   TestA
+    a_test.go:5: setting up
+--- PASS: TestA (0.00s)
+=== RUN   TestB
+    b_test.go:9: got 1, want 2
+--- FAIL: TestB (0.00s)
+=== RUN   TestC
+--- PASS: TestC (0.00s)
+    --- PASS: TestC/sub (0.00s)
+FAIL
+FAIL	example.com/pkg	0.010s
+`
+	got := apply([]byte(input), Options{Parser: "gotest"})
+	want := "    b_test.go:9: got 1, want 2\n--- FAIL: TestB (0.00s)\nFAIL\nFAIL\texample.com/pkg\t0.010s"
+	if got != want {
+		t.Errorf("unexpected output:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestParseCargo(t *testing.T) {
+	input := `   Compiling mylib v0.3.1 (/home/user/mylib)
+    Finished test profile [unoptimized + debuginfo] target(s) in 4.23s
+     Running unittests src/lib.rs (target/debug/deps/mylib-a1b2c3d4e5f6)
+
+running 3 tests
+test auth::tests::test_hash ... ok
+test db::tests::test_pool ... FAILED
+test models::tests::test_new ... ok
+
+failures:
+
+---- db::tests::test_pool stdout ----
+thread 'db::tests::test_pool' panicked at src/db.rs:42:9:
+assertion ` + "`left == right`" + ` failed
+  left: 11
+ right: 10
+
+failures:
+    db::tests::test_pool
+
+test result: FAILED. 2 passed; 1 failed; 0 ignored; 0 measured; 0 filtered out; finished in 0.01s
+
+error: test failed, to rerun pass ` + "`--lib`" + `
+`
+	got := apply([]byte(input), Options{Parser: "cargo"})
+	for _, want := range []string{"test db::tests::test_pool ... FAILED", "panicked at src/db.rs:42:9", "  left: 11", "test result: FAILED", "error: test failed"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in output:\n%s", want, got)
+		}
+	}
+	for _, noise := range []string{"Compiling", "test_hash ... ok", "running 3 tests"} {
+		if strings.Contains(got, noise) {
+			t.Errorf("expected %q to be dropped:\n%s", noise, got)
+		}
+	}
+}
+
+func TestParseTSC(t *testing.T) {
+	input := `[12:00:00] Starting compilation in watch mode...
+
+src/auth.ts:42:3 - error TS2304: Cannot find name 'userId'.
+
+42   return userId;
+     ~~~~~~
+
+src/api.ts:7:1 - error TS2322: Type 'string' is not assignable to type 'number'.
+
+7 const n: number = "a";
+  ~~~~~~~~~~~~~~~
+
+Found 2 errors in 2 files.
+`
+	got := apply([]byte(input), Options{Parser: "tsc"})
+	if strings.Contains(got, "Starting compilation") {
+		t.Errorf("expected watch banner dropped:\n%s", got)
+	}
+	for _, want := range []string{"error TS2304", "42   return userId;", "error TS2322", "Found 2 errors in 2 files."} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in output:\n%s", want, got)
+		}
+	}
+}
+
+func TestParseESLint(t *testing.T) {
+	input := `
+/app/src/clean.js
+  3:1  warning  Unexpected console statement  no-console
+
+/app/src/broken.js
+  1:10  error    'foo' is defined but never used  no-unused-vars
+  2:1   warning  Unexpected console statement     no-console
+
+✖ 3 problems (1 error, 2 warnings)
+`
+	got := apply([]byte(input), Options{Parser: "eslint"})
+	want := "/app/src/broken.js\n  1:10  error    'foo' is defined but never used  no-unused-vars\n\n✖ 3 problems (1 error, 2 warnings)"
+	if got != want {
+		t.Errorf("unexpected output:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestParserUnrecognisedOutputPassesThrough(t *testing.T) {
+	input := "something went wrong\n"
+	if got := apply([]byte(input), Options{Parser: "pytest"}); got != input {
+		t.Errorf("expected passthrough, got %q", got)
+	}
+	if got := apply([]byte(input), Options{Parser: "auto"}); got != input {
+		t.Errorf("expected passthrough for auto, got %q", got)
+	}
+}
+
+func TestIsParser(t *testing.T) {
+	for _, name := range ParserNames() {
+		if !IsParser(name) {
+			t.Errorf("expected %q to be a parser", name)
+		}
+	}
+	if IsParser("maven") {
+		t.Error("expected unknown parser to be rejected")
+	}
+}
@@ -0,0 +1,145 @@
+package filter
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"regexp"
+)
+
+// ApplyReader is Apply for output too large to hold in memory: it reads r
+// line by line and keeps only what the line range, grep and head/tail
+// stages select. Parsers need the whole output and are not applied.
+func ApplyReader(r io.Reader, opts Options) ([]byte, Stats, error) {
+	var g *grepper
+	if opts.Grep != "" {
+		var err error
+		if g, err = newGrepper(opts.Grep, opts.GrepBefore, opts.GrepAfter); err != nil {
+			g = nil // invalid pattern, as in Apply
+		}
+	}
+	ends := headTail{head: opts.Head, tail: opts.Tail}
+
+	var all []line
+	total := 0
+	err := eachLine(r, func(text []byte) {
+		n := total
+		total++
+		if opts.Lines.Start > 0 && (n+1 < opts.Lines.Start || opts.Lines.End > 0 && n+1 > opts.Lines.End) {
+			return
+		}
+		if opts.StripANSI {
+			text = StripANSI(text)
+		}
+		l := line{n: n, text: bytes.Clone(text)}
+		switch {
+		case g != nil:
+			g.line(l)
+		case ends.head > 0 || ends.tail > 0:
+			ends.line(l)
+		default:
+			all = append(all, l)
+		}
+	})
+	if err != nil {
+		return nil, Stats{}, err
+	}
+
+	lines := all
+	switch {
+	case g != nil:
+		lines = g.kept
+		if opts.Head > 0 || opts.Tail > 0 {
+			lines = applyHeadTail(lines, opts.Head, opts.Tail)
+		}
+	case ends.head > 0 || ends.tail > 0:
+		lines = ends.result()
+	}
+	if opts.MaxTokens > 0 {
+		lines = applyTokenBudget(lines, opts.MaxTokens, opts.Tokens)
+	}
+
+	stats := Stats{TotalLines: total}
+	stats.KeptLines, stats.Elided, stats.Marked = elided(lines, total)
+	return joinTagged(lines), stats, nil
+}
+
+// MatchReader is MatchLines for output read line by line from r, with
+// ANSI escapes stripped.
+func MatchReader(r io.Reader, pattern string) (MatchResult, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return MatchResult{}, nil
+	}
+
+	var matched [][]byte
+	err = eachLine(r, func(text []byte) {
+		text = StripANSI(text)
+		if re.Match(text) {
+			matched = append(matched, bytes.Clone(text))
+		}
+	})
+	if err != nil {
+		return MatchResult{}, err
+	}
+	return MatchResult{
+		Lines: bytes.Join(matched, []byte("\n")),
+		Count: len(matched),
+	}, nil
+}
+
+// headTail is the head/tail stage run one line at a time: it keeps the
+// first head lines and a window of the last tail lines.
+type headTail struct {
+	head, tail int
+	first      []line
+	last       []line
+	seen       int
+}
+
+func (h *headTail) line(l line) {
+	h.seen++
+	if len(h.first) < h.head {
+		h.first = append(h.first, l)
+		return
+	}
+	if h.tail > 0 {
+		if len(h.last) == h.tail {
+			h.last = append(h.last[:0], h.last[1:]...)
+		}
+		h.last = append(h.last, l)
+	}
+}
+
+// result returns what applyHeadTail would keep of all the lines seen.
+func (h *headTail) result() []line {
+	switch {
+	case h.head > 0 && h.tail > 0:
+		kept := append([]line{}, h.first...)
+		if omitted := h.seen - len(h.first) - len(h.last); omitted > 0 {
+			kept = append(kept, headTailMarker(omitted))
+		}
+		return append(kept, h.last...)
+	case h.tail > 0:
+		return h.last
+	}
+	return h.first
+}
+
+// eachLine calls fn with each line of r, without its line ending.
+func eachLine(r io.Reader, fn func(text []byte)) error {
+	br := bufio.NewReader(r)
+	for {
+		text, err := br.ReadBytes('\n')
+		if len(text) > 0 {
+			fn(bytes.TrimSuffix(text, []byte("\n")))
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestApplyReaderMatchesApply checks that streaming gives the same result
+// as filtering in memory.
+func TestApplyReaderMatchesApply(t *testing.T) {
+	var sb strings.Builder
+	for i := 1; i <= 50; i++ {
+		if i%7 == 0 {
+			fmt.Fprintf(&sb, "\x1b[31merror %d\x1b[0m\n", i)
+		} else {
+			fmt.Fprintf(&sb, "ok %d\n", i)
+		}
+	}
+	input := sb.String()
+
+	for _, opts := range []Options{
+		{},
+		{Head: 3},
+		{Tail: 4},
+		{Head: 2, Tail: 2},
+		{Head: 30, Tail: 30},
+		{Grep: "error"},
+		{Grep: "error", GrepBefore: 1, GrepAfter: 2},
+		{Grep: "error", GrepBefore: 3, GrepAfter: 3, Tail: 5},
+		{Lines: Range{Start: 10, End: 20}, Grep: "error"},
+		{MaxTokens: 40},
+	} {
+		opts.StripANSI = true
+		want, wantStats := Apply([]byte(input), opts)
+		got, gotStats, err := ApplyReader(strings.NewReader(input), opts)
+		if err != nil {
+			t.Fatalf("%+v: unexpected error: %v", opts, err)
+		}
+		if strings.TrimSuffix(string(got), "\n") != strings.TrimSuffix(string(want), "\n") {
+			t.Errorf("%+v: output differs:\n got: %q\nwant: %q", opts, got, want)
+		}
+		if !reflect.DeepEqual(gotStats, wantStats) {
+			t.Errorf("%+v: stats differ:\n got: %+v\nwant: %+v", opts, gotStats, wantStats)
+		}
+	}
+}
+
+func TestMatchReader(t *testing.T) {
+	got, err := MatchReader(strings.NewReader("info\n\x1b[33mwarning: a\x1b[0m\nwarning: b"), "^warning")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Count != 2 || string(got.Lines) != "warning: a\nwarning: b" {
+		t.Errorf("unexpected matches: %d %q", got.Count, got.Lines)
+	}
+}
+
+func TestStatsWithGap(t *testing.T) {
+	// Lines 1-3 captured, line 4 a marker for 100 lines, lines 5-6 captured.
+	tests := []struct {
+		name string
+		in   Stats
+		want Stats
+	}{
+		{
+			"marker shown",
+			Stats{TotalLines: 6, KeptLines: 6},
+			Stats{TotalLines: 105, KeptLines: 5, Elided: []Range{{4, 103}}, Marked: 100},
+		},
+		{
+			"marker elided",
+			Stats{TotalLines: 6, KeptLines: 2, Elided: []Range{{1, 4}}},
+			Stats{TotalLines: 105, KeptLines: 2, Elided: []Range{{1, 103}}},
+		},
+		{
+			"ranges after the marker shift",
+			Stats{TotalLines: 6, KeptLines: 4, Elided: []Range{{2, 2}, {6, 6}}},
+			Stats{TotalLines: 105, KeptLines: 3, Elided: []Range{{2, 2}, {4, 103}, {105, 105}}, Marked: 100},
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.in.WithGap(4, 100); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got: %+v\nwant: %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// TokenCounter estimates how many tokens b costs an LLM.
+type TokenCounter func(b []byte) int
+
+// EstimateTokens approximates tokens as chars/4, the cl100k_base average
+// also used by benchmark/run.sh.
+func EstimateTokens(b []byte) int {
+	return (len(b) + 3) / 4
+}
+
+// applyTokenBudget trims lines to roughly maxTokens, keeping the head and
+// tail halves and replacing the middle with a marker saying what was omitted.
+func applyTokenBudget(lines []line, maxTokens int, count TokenCounter) []line {
+	if count == nil {
+		count = EstimateTokens
+	}
+	if count(joinTagged(lines)) <= maxTokens {
+		return lines
+	}
+
+	cost := make([]int, len(lines))
+	for i, l := range lines {
+		cost[i] = count(l.text)
+	}
+
+	half := maxTokens / 2
+	head, used := 0, 0
+	for head < len(lines) && used+cost[head] <= half {
+		used += cost[head]
+		head++
+	}
+	tail, used := len(lines), 0
+	for tail > head && used+cost[tail-1] <= half {
+		used += cost[tail-1]
+		tail--
+	}
+	if tail <= head {
+		return lines
+	}
+
+	omitted := 0
+	for _, c := range cost[head:tail] {
+		omitted += c
+	}
+	n := omittedLines(lines[head:tail])
+	text := fmt.Sprintf("… %s lines (≈%s tokens) omitted …", GroupThousands(n), approxTokens(omitted))
+
+	kept := make([]line, 0, head+1+len(lines)-tail)
+	kept = append(kept, lines[:head]...)
+	kept = append(kept, line{n: -1, text: []byte(text), omits: n})
+	kept = append(kept, lines[tail:]...)
+	return kept
+}
+
+// GroupThousands formats n with comma separators: 1234 -> "1,234".
+func GroupThousands(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// approxTokens renders a token count loosely: 940 -> "940", 9120 -> "9k".
+func approxTokens(n int) string {
+	if n < 1000 {
+		return strconv.Itoa(n)
+	}
+	return strconv.Itoa((n+500)/1000) + "k"
+}
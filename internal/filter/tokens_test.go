@@ -0,0 +1,55 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestApplyMaxTokensWithinBudget(t *testing.T) {
+	input := "short output\n"
+	if got := apply([]byte(input), Options{MaxTokens: 100}); got != input {
+		t.Errorf("expected passthrough, got %q", got)
+	}
+}
+
+func TestApplyMaxTokensKeepsHeadAndTail(t *testing.T) {
+	var sb strings.Builder
+	for i := 1; i <= 2000; i++ {
+		fmt.Fprintf(&sb, "line %04d of noisy build output\n", i)
+	}
+	got := apply([]byte(sb.String()), Options{MaxTokens: 200})
+
+	if !strings.HasPrefix(got, "line 0001") {
+		t.Errorf("expected head kept, got prefix %q", got[:20])
+	}
+	if !strings.HasSuffix(got, "line 2000 of noisy build output") {
+		t.Errorf("expected tail kept, got suffix %q", got[len(got)-40:])
+	}
+	if !strings.Contains(got, " lines (≈") || !strings.Contains(got, "k tokens) omitted …") {
+		t.Errorf("expected omission marker, got:\n%s", got)
+	}
+	if tokens := EstimateTokens([]byte(got)); tokens > 220 {
+		t.Errorf("expected output near budget, got ≈%d tokens", tokens)
+	}
+}
+
+func TestApplyMaxTokensCustomCounter(t *testing.T) {
+	// One token per line makes the budget a line count
+	perLine := func(b []byte) int { return strings.Count(string(b), "\n") + 1 }
+	input := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	got := apply([]byte(input), Options{MaxTokens: 4, Tokens: perLine})
+	want := "a\nb\n… 4 lines (≈4 tokens) omitted …\ng\nh"
+	if got != want {
+		t.Errorf("unexpected output:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestGroupThousands(t *testing.T) {
+	tests := map[int]string{0: "0", 999: "999", 1234: "1,234", 1234567: "1,234,567"}
+	for n, want := range tests {
+		if got := GroupThousands(n); got != want {
+			t.Errorf("GroupThousands(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+// Package history stores the raw output of past runs so it can be
+// re-filtered later without running the command again.
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alfranz/hush/internal/filter"
+)
+
+// DefaultKeep is how many runs a Store keeps when Keep is not set.
+const DefaultKeep = 20
+
+// DefaultMaxLog is how much of a run's complete output a Store keeps when
+// MaxLog is not set.
+const DefaultMaxLog = 64 << 20
+
+// ErrNoRuns is returned by Last when no matching run has been recorded.
+var ErrNoRuns = errors.New("no recorded runs")
+
+// Run is the metadata recorded for one command run.
+type Run struct {
+	ID       string        `json:"id"`
+	Label    string        `json:"label"`
+	Command  string        `json:"command"`
+	ExitCode int           `json:"exit_code"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+	TimedOut bool          `json:"timed_out,omitempty"`
+	// Attempt is the attempt whose output was recorded, out of at most
+	// Attempts.
+	Attempt  int `json:"attempt,omitempty"`
+	Attempts int `json:"attempts,omitempty"`
+	// FirstFailure is the first failed attempt of a run that passed on a
+	// retry.
+	FirstFailure *Failure `json:"first_failure,omitempty"`
+	// Filter is how the output was filtered when it was first shown.
+	Filter Filter `json:"filter"`
+}
+
+// Filter records the filtering settings of a run.
+type Filter struct {
+	Head       int    `json:"head,omitempty"`
+	Tail       int    `json:"tail,omitempty"`
+	Grep       string `json:"grep,omitempty"`
+	GrepBefore int    `json:"grep_before,omitempty"`
+	GrepAfter  int    `json:"grep_after,omitempty"`
+	Parser     string `json:"parser,omitempty"`
+	MaxTokens  int    `json:"max_tokens,omitempty"`
+	Stream     string `json:"stream,omitempty"`
+}
+
+// Failure is a failed attempt of a run, with its output filtered as it was
+// shown.
+type Failure struct {
+	ExitCode int    `json:"exit_code"`
+	Output   string `json:"output"`
+}
+
+// Output is the recorded output of a run.
+type Output struct {
+	// Combined interleaves stdout and stderr.
+	Combined []byte
+	// CombinedFile, if set, holds the complete combined output and is
+	// saved in place of Combined.
+	CombinedFile string
+	Stdout       []byte
+	Stderr       []byte
+}
+
+// Store keeps runs in a directory: per run an <id>.json metadata file, the
+// combined output in <id>.log and the separate streams in <id>.stdout and
+// <id>.stderr.
+type Store struct {
+	Dir string
+	// Keep is the number of most recent runs to retain; DefaultKeep if
+	// zero or negative.
+	Keep int
+	// MaxLog caps the bytes kept of a CombinedFile; DefaultMaxLog if zero
+	// or negative. The middle of larger output is left out.
+	MaxLog int64
+}
+
+// Save records run with its raw output and prunes runs beyond the
+// retention limit. run.ID is assigned from the save time, so IDs sort in
+// the order runs were saved.
+func (s *Store) Save(run *Run, output Output) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	saved := time.Now().UTC()
+	if run.Started.IsZero() {
+		run.Started = saved
+	}
+	for {
+		run.ID = saved.Format("20060102T150405.000000000Z")
+		// Coarse clocks can repeat a timestamp between two saves.
+		if _, err := os.Stat(s.path(run.ID, ".json")); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		saved = saved.Add(time.Nanosecond)
+	}
+
+	// Write the output first: a run is only listed once its metadata exists.
+	for ext, b := range map[string][]byte{".log": output.Combined, ".stdout": output.Stdout, ".stderr": output.Stderr} {
+		if ext == ".log" && output.CombinedFile != "" {
+			if err := copyLog(s.path(run.ID, ext), output.CombinedFile, s.maxLog()); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.WriteFile(s.path(run.ID, ext), b, 0o644); err != nil {
+			return err
+		}
+	}
+	meta, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path(run.ID, ".json"), meta, 0o644); err != nil {
+		return err
+	}
+	return s.prune()
+}
+
+// Last returns the most recent run with the given label, or the most recent
+// run of all if label is empty.
+func (s *Store) Last(label string) (*Run, error) {
+	ids, err := s.ids()
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range slices.Backward(ids) {
+		data, err := os.ReadFile(s.path(id, ".json"))
+		if err != nil {
+			continue // pruned by a concurrent run
+		}
+		var run Run
+		if err := json.Unmarshal(data, &run); err != nil {
+			return nil, fmt.Errorf("%s: %w", s.path(id, ".json"), err)
+		}
+		if label == "" || run.Label == label {
+			return &run, nil
+		}
+	}
+	if label != "" {
+		return nil, fmt.Errorf("%w labelled %q", ErrNoRuns, label)
+	}
+	return nil, ErrNoRuns
+}
+
+// Open opens the raw output recorded for run: "stdout", "stderr", or by
+// default both, interleaved.
+func (s *Store) Open(run *Run, stream string) (*os.File, error) {
+	ext := ".log"
+	switch stream {
+	case "stdout", "stderr":
+		ext = "." + stream
+	}
+	return os.Open(s.path(run.ID, ext))
+}
+
+// ids lists recorded run IDs, oldest first.
+func (s *Store) ids() ([]string, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, e := range entries {
+		if id, ok := strings.CutSuffix(e.Name(), ".json"); ok {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func (s *Store) prune() error {
+	keep := s.Keep
+	if keep <= 0 {
+		keep = DefaultKeep
+	}
+	ids, err := s.ids()
+	if err != nil || len(ids) <= keep {
+		return err
+	}
+	for _, id := range ids[:len(ids)-keep] {
+		for _, ext := range []string{".json", ".log", ".stdout", ".stderr"} {
+			if err := os.Remove(s.path(id, ext)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Store) maxLog() int64 {
+	if s.MaxLog <= 0 {
+		return DefaultMaxLog
+	}
+	return s.MaxLog
+}
+
+func (s *Store) path(id, ext string) string {
+	return filepath.Join(s.Dir, id+ext)
+}
+
+// copyLog copies src to dst. If src is larger than max bytes, only its first
+// and last max/2 bytes are kept, cut at line boundaries, around a marker line
+// counting the lines left out.
+func copyLog(dst, src string, max int64) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if info.Size() <= max {
+		_, err = io.Copy(out, in)
+	} else {
+		err = copyEnds(out, in, info.Size(), max/2)
+	}
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// copyEnds writes the first and last half bytes of in, which is size bytes
+// long, to w.
+func copyEnds(w io.Writer, in io.ReaderAt, size, half int64) error {
+	head := make([]byte, half)
+	if _, err := in.ReadAt(head, 0); err != nil {
+		return err
+	}
+	head = head[:bytes.LastIndexByte(head, '\n')+1]
+
+	// Read one byte before the tail to see whether it starts a line; a
+	// line it starts partway through is left out.
+	from := size - half - 1
+	tail := make([]byte, half+1)
+	if _, err := in.ReadAt(tail, from); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	cut := bytes.IndexByte(tail, '\n') + 1
+	if cut == len(tail) {
+		cut = 1
+	}
+	dropped, err := countLines(io.NewSectionReader(in, int64(len(head)), from+int64(cut)-int64(len(head))))
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(head); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "… %s lines not recorded …\n", filter.GroupThousands(dropped)); err != nil {
+		return err
+	}
+	_, err = w.Write(tail[cut:])
+	return err
+}
+
+// countLines counts the line breaks in r.
+func countLines(r io.Reader) (int, error) {
+	buf := make([]byte, 64<<10)
+	n := 0
+	for {
+		read, err := r.Read(buf)
+		n += bytes.Count(buf[:read], []byte("\n"))
+		if errors.Is(err, io.EOF) {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
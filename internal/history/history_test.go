@@ -0,0 +1,119 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveAndLast(t *testing.T) {
+	s := &Store{Dir: t.TempDir()}
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	lint := &Run{Label: "lint", Command: "ruff check .", ExitCode: 1, Started: start}
+	if err := s.Save(lint, Output{Combined: []byte("E501 line too long\n"), Stderr: []byte("E501 line too long\n")}); err != nil {
+		t.Fatal(err)
+	}
+	test := &Run{Label: "test", Command: "pytest", Started: start.Add(time.Second), Filter: Filter{Parser: "pytest"}}
+	if err := s.Save(test, Output{Combined: []byte("1 passed\n"), Stdout: []byte("1 passed\n")}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Last("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Label != "test" || got.Filter.Parser != "pytest" {
+		t.Errorf("expected the most recent run, got %+v", got)
+	}
+
+	got, err = s.Last("lint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Command != "ruff check ." || got.ExitCode != 1 {
+		t.Errorf("unexpected run: %+v", got)
+	}
+	for stream, want := range map[string]string{"": "E501 line too long\n", "stderr": "E501 line too long\n", "stdout": ""} {
+		f, err := s.Open(got, stream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(f)
+		f.Close()
+		if string(data) != want {
+			t.Errorf("stream %q: expected %q, got %q", stream, want, data)
+		}
+	}
+}
+
+func TestSaveCapsLog(t *testing.T) {
+	dir := t.TempDir()
+	spill := filepath.Join(dir, "spill.log")
+	var b strings.Builder
+	for i := 1; i <= 1000; i++ {
+		fmt.Fprintf(&b, "line %04d\n", i)
+	}
+	os.WriteFile(spill, []byte(b.String()), 0644)
+
+	s := &Store{Dir: filepath.Join(dir, "runs"), MaxLog: 100}
+	run := &Run{Label: "big"}
+	if err := s.Save(run, Output{CombinedFile: spill}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := s.Open(run, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	data, _ := io.ReadAll(f)
+	want := "line 0001\nline 0002\nline 0003\nline 0004\nline 0005\n… 990 lines not recorded …\nline 0996\nline 0997\nline 0998\nline 0999\nline 1000\n"
+	if string(data) != want {
+		t.Errorf("unexpected log:\n%s", data)
+	}
+}
+
+func TestLastNoRuns(t *testing.T) {
+	s := &Store{Dir: t.TempDir() + "/missing"}
+	if _, err := s.Last(""); !errors.Is(err, ErrNoRuns) {
+		t.Errorf("expected ErrNoRuns, got %v", err)
+	}
+
+	if err := s.Save(&Run{Label: "lint"}, Output{}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := s.Last("build")
+	if !errors.Is(err, ErrNoRuns) || err.Error() != `no recorded runs labelled "build"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSavePrunesOldRuns(t *testing.T) {
+	s := &Store{Dir: t.TempDir(), Keep: 2}
+	start := time.Now()
+	for i := range 4 {
+		if err := s.Save(&Run{Label: "test", Started: start.Add(time.Duration(i) * time.Second)}, Output{Combined: []byte("out")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 8 {
+		t.Errorf("expected 2 runs (8 files) kept, got %d files", len(entries))
+	}
+	got, err := s.Last("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Started.Equal(start.Add(3 * time.Second)) {
+		t.Errorf("expected the newest run to survive, got %v", got.Started)
+	}
+}
@@ -0,0 +1,213 @@
+// Package mcp implements a Model Context Protocol server offering tools over
+// stdio: newline-delimited JSON-RPC 2.0 messages.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// ProtocolVersion is the newest protocol version the server speaks.
+const ProtocolVersion = "2025-06-18"
+
+// protocolVersions lists the versions a client may ask for.
+var protocolVersions = []string{"2024-11-05", "2025-03-26", ProtocolVersion}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Tool is a tool the server offers.
+type Tool struct {
+	Name        string
+	Description string
+	// InputSchema is the JSON schema of the tool's arguments.
+	InputSchema map[string]any
+	// Call runs the tool. An error is reported to the client as a failed
+	// call, not as a protocol error, so that the model can see it.
+	Call func(ctx context.Context, args json.RawMessage) (Result, error)
+}
+
+// Result is the outcome of a tool call.
+type Result struct {
+	// Text is shown to the model.
+	Text string
+	// Structured is the result as a JSON object, if there is one.
+	Structured json.RawMessage
+	// IsError marks a call that ran but failed.
+	IsError bool
+}
+
+// Server answers requests for its tools.
+type Server struct {
+	Name    string
+	Version string
+	Tools   []Tool
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// Serve reads requests from r and writes responses to w, one per line,
+// until r is exhausted or ctx is done. Requests are handled one at a time.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	in := bufio.NewReader(r)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for ctx.Err() == nil {
+		line, err := in.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			if resp := s.handle(ctx, line); resp != nil {
+				if err := enc.Encode(resp); err != nil {
+					return err
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handle answers a single message, returning nil for notifications.
+func (s *Server) handle(ctx context.Context, msg []byte) *response {
+	var req request
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, "parse error: " + err.Error()}}
+	}
+	if req.ID == nil {
+		// Notifications need no answer
+		return nil
+	}
+	resp := &response{JSONRPC: "2.0", ID: req.ID}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &rpcError{codeInvalidRequest, "invalid request"}
+		return resp
+	}
+	result, err := s.dispatch(ctx, req.Method, req.Params)
+	if err != nil {
+		rerr, ok := err.(*rpcError)
+		if !ok {
+			rerr = &rpcError{codeInvalidParams, err.Error()}
+		}
+		resp.Error = rerr
+		return resp
+	}
+	resp.Result = result
+	return resp
+}
+
+func (s *Server) dispatch(ctx context.Context, method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "initialize":
+		return s.initialize(params)
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return s.listTools(), nil
+	case "tools/call":
+		return s.callTool(ctx, params)
+	}
+	return nil, &rpcError{codeMethodNotFound, "method not found: " + method}
+}
+
+func (s *Server) initialize(params json.RawMessage) (any, error) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+	}
+	// Agree to the client's version if we speak it, else offer ours
+	version := ProtocolVersion
+	if slices.Contains(protocolVersions, p.ProtocolVersion) {
+		version = p.ProtocolVersion
+	}
+	return map[string]any{
+		"protocolVersion": version,
+		"capabilities":    map[string]any{"tools": map[string]any{}},
+		"serverInfo":      map[string]any{"name": s.Name, "version": s.Version},
+	}, nil
+}
+
+func (s *Server) listTools() any {
+	tools := make([]map[string]any, len(s.Tools))
+	for i, t := range s.Tools {
+		schema := t.InputSchema
+		if schema == nil {
+			schema = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		tools[i] = map[string]any{
+			"name":        t.Name,
+			"description": t.Description,
+			"inputSchema": schema,
+		}
+	}
+	return map[string]any{"tools": tools}
+}
+
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (any, error) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(s.Tools, func(t Tool) bool { return t.Name == p.Name })
+	if i < 0 {
+		return nil, fmt.Errorf("unknown tool %q", p.Name)
+	}
+	args := p.Arguments
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+
+	result, err := s.Tools[i].Call(ctx, args)
+	if err != nil {
+		result = Result{Text: err.Error(), IsError: true}
+	}
+	content := map[string]any{
+		"content": []map[string]any{{"type": "text", "text": result.Text}},
+		"isError": result.IsError,
+	}
+	if result.Structured != nil {
+		content["structuredContent"] = result.Structured
+	}
+	return content, nil
+}
@@ -0,0 +1,115 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// serve runs the server on the given request lines and returns the decoded
+// responses.
+func serve(t *testing.T, s *Server, requests ...string) []map[string]any {
+	t.Helper()
+	var out strings.Builder
+	if err := s.Serve(t.Context(), strings.NewReader(strings.Join(requests, "\n")), &out); err != nil {
+		t.Fatal(err)
+	}
+	var responses []map[string]any
+	scanner := bufio.NewScanner(strings.NewReader(out.String()))
+	for scanner.Scan() {
+		var resp map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response %q: %v", scanner.Text(), err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func echoServer() *Server {
+	return &Server{Name: "hush", Version: "v1.0.0", Tools: []Tool{{
+		Name:        "echo",
+		Description: "Echo a message",
+		Call: func(ctx context.Context, args json.RawMessage) (Result, error) {
+			var a struct{ Message string }
+			json.Unmarshal(args, &a)
+			if a.Message == "" {
+				return Result{}, errors.New("message is required")
+			}
+			return Result{Text: a.Message, Structured: json.RawMessage(`{"message":"` + a.Message + `"}`)}, nil
+		},
+	}}}
+}
+
+func TestServeSession(t *testing.T) {
+	responses := serve(t, echoServer(),
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"0"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo","arguments":{"message":"hi"}}}`,
+		`{"jsonrpc":"2.0","id":"4","method":"ping"}`,
+	)
+	if len(responses) != 4 {
+		t.Fatalf("expected 4 responses, got %d: %v", len(responses), responses)
+	}
+
+	init := responses[0]["result"].(map[string]any)
+	if init["protocolVersion"] != "2025-03-26" {
+		t.Errorf("expected the client's protocol version, got %v", init["protocolVersion"])
+	}
+	if info := init["serverInfo"].(map[string]any); info["name"] != "hush" || info["version"] != "v1.0.0" {
+		t.Errorf("unexpected server info: %v", info)
+	}
+
+	tools := responses[1]["result"].(map[string]any)["tools"].([]any)
+	tool := tools[0].(map[string]any)
+	if len(tools) != 1 || tool["name"] != "echo" || tool["inputSchema"].(map[string]any)["type"] != "object" {
+		t.Errorf("unexpected tools: %v", tools)
+	}
+
+	call := responses[2]["result"].(map[string]any)
+	text := call["content"].([]any)[0].(map[string]any)["text"]
+	if text != "hi" || call["isError"] != false {
+		t.Errorf("unexpected call result: %v", call)
+	}
+	if structured := call["structuredContent"].(map[string]any); structured["message"] != "hi" {
+		t.Errorf("unexpected structured content: %v", structured)
+	}
+
+	if responses[3]["id"] != "4" || responses[3]["result"] == nil {
+		t.Errorf("unexpected ping response: %v", responses[3])
+	}
+}
+
+func TestServeInitializeUnknownVersion(t *testing.T) {
+	responses := serve(t, echoServer(), `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"1999-01-01"}}`)
+	if got := responses[0]["result"].(map[string]any)["protocolVersion"]; got != ProtocolVersion {
+		t.Errorf("expected %s, got %v", ProtocolVersion, got)
+	}
+}
+
+func TestServeErrors(t *testing.T) {
+	responses := serve(t, echoServer(),
+		`not json`,
+		`{"jsonrpc":"2.0","id":1,"method":"resources/list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"nope"}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo","arguments":{}}}`,
+	)
+	codes := []float64{codeParseError, codeMethodNotFound, codeInvalidParams}
+	for i, code := range codes {
+		rerr, ok := responses[i]["error"].(map[string]any)
+		if !ok || rerr["code"] != code {
+			t.Errorf("response %d: expected error code %v, got %v", i, code, responses[i])
+		}
+	}
+
+	// A tool that fails is reported to the model, not as a protocol error
+	call := responses[3]["result"].(map[string]any)
+	text := call["content"].([]any)[0].(map[string]any)["text"]
+	if call["isError"] != true || text != "message is required" {
+		t.Errorf("expected a failed call, got %v", call)
+	}
+}
@@ -0,0 +1,155 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/alfranz/hush/internal/filter"
+)
+
+type jsonResult struct {
+	Type    string `json:"type"`
+	Label   string `json:"label"`
+	Command string `json:"command"`
+	// ExitCode is null for a command that did not run.
+	ExitCode     *int           `json:"exit_code"`
+	DurationMS   int64          `json:"duration_ms"`
+	Status       Status         `json:"status"`
+	Output       string         `json:"output"`
+	WarningCount int            `json:"warning_count"`
+	Warnings     []string       `json:"warnings"`
+	Truncation   jsonTruncation `json:"truncation"`
+	TimeoutMS    int64          `json:"timeout_ms,omitempty"`
+	SkipReason   string         `json:"skip_reason,omitempty"`
+	SlowMS       int64          `json:"slow_threshold_ms,omitempty"`
+	Attempt      int            `json:"attempt,omitempty"`
+	Attempts     int            `json:"attempts,omitempty"`
+	FirstFailure *jsonFailure   `json:"first_failure,omitempty"`
+	Cached       bool           `json:"cached,omitempty"`
+}
+
+type jsonFailure struct {
+	ExitCode int    `json:"exit_code"`
+	Output   string `json:"output"`
+}
+
+type jsonTruncation struct {
+	Truncated  bool           `json:"truncated"`
+	TotalLines int            `json:"total_lines"`
+	ShownLines int            `json:"shown_lines"`
+	Elided     []filter.Range `json:"elided,omitempty"`
+}
+
+type jsonSummary struct {
+	Type       string `json:"type"`
+	Status     Status `json:"status"`
+	Passed     int    `json:"passed"`
+	Total      int    `json:"total"`
+	Stopped    bool   `json:"stopped"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// jsonFormatter writes one JSON document on Close, or one object per line
+// as results arrive when stream is set (ndjson).
+type jsonFormatter struct {
+	w       io.Writer
+	stream  bool
+	results []jsonResult
+	summary *jsonSummary
+}
+
+func (f *jsonFormatter) Result(r Report) {
+	jr := jsonResult{
+		Type:         "result",
+		Label:        r.Label,
+		Command:      r.Command,
+		DurationMS:   r.Duration.Milliseconds(),
+		Status:       r.Status,
+		Output:       strings.TrimSuffix(string(r.Output), "\n"),
+		WarningCount: r.WarningCount,
+		Warnings:     splitLines(r.Warnings),
+		Truncation: jsonTruncation{
+			Truncated:  r.ShownLines < r.TotalLines,
+			TotalLines: r.TotalLines,
+			ShownLines: r.ShownLines,
+			Elided:     r.Elided,
+		},
+		TimeoutMS:  r.Timeout.Milliseconds(),
+		SkipReason: r.SkipReason,
+		SlowMS:     r.Slow.Milliseconds(),
+		Cached:     r.Cached,
+	}
+	if r.Status != StatusSkipped && !r.Cached {
+		jr.ExitCode = &r.ExitCode
+	}
+	// Attempts are only reported when retries were allowed
+	if r.Attempts > 1 {
+		jr.Attempt, jr.Attempts = r.Attempt, r.Attempts
+	}
+	if r.FirstFailure != nil {
+		jr.FirstFailure = &jsonFailure{
+			ExitCode: r.FirstFailure.ExitCode,
+			Output:   strings.TrimSuffix(string(r.FirstFailure.Output), "\n"),
+		}
+	}
+	if f.stream {
+		f.encode(jr, "")
+		return
+	}
+	f.results = append(f.results, jr)
+}
+
+func (f *jsonFormatter) Summary(s Summary) {
+	js := &jsonSummary{
+		Type:       "summary",
+		Status:     StatusPass,
+		Passed:     s.Passed,
+		Total:      s.Total,
+		Stopped:    s.Stopped,
+		DurationMS: s.Duration.Milliseconds(),
+	}
+	if s.Passed != s.Total {
+		js.Status = StatusFail
+	}
+	if f.stream {
+		f.encode(js, "")
+		return
+	}
+	f.summary = js
+}
+
+func (f *jsonFormatter) Close() error {
+	if f.stream {
+		return nil
+	}
+	// A single command is reported as a bare result object
+	if f.summary == nil && len(f.results) == 1 {
+		return f.encode(f.results[0], "  ")
+	}
+	results := f.results
+	if results == nil {
+		results = []jsonResult{}
+	}
+	return f.encode(struct {
+		Results []jsonResult `json:"results"`
+		Summary *jsonSummary `json:"summary,omitempty"`
+	}{results, f.summary}, "  ")
+}
+
+func (f *jsonFormatter) encode(v any, indent string) error {
+	enc := json.NewEncoder(f.w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	return enc.Encode(v)
+}
+
+func splitLines(b []byte) []string {
+	lines := []string{}
+	for _, line := range strings.Split(string(b), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,169 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alfranz/hush/internal/filter"
+)
+
+func TestJSONFormatterSingleResult(t *testing.T) {
+	var buf bytes.Buffer
+	f, err := NewFormatter(&buf, "json", FormatOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f.Result(Report{
+		Label:        "tsc",
+		Command:      "tsc --noEmit",
+		Duration:     1500 * time.Millisecond,
+		Status:       StatusWarn,
+		WarningCount: 2,
+		Warnings:     []byte("w1\nw2\n"),
+		TotalLines:   10,
+		ShownLines:   10,
+	})
+	if err := f.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if got["label"] != "tsc" || got["status"] != "warn" || got["duration_ms"] != float64(1500) {
+		t.Errorf("unexpected result: %v", got)
+	}
+	if warnings, _ := got["warnings"].([]any); len(warnings) != 2 {
+		t.Errorf("expected 2 warning lines, got %v", got["warnings"])
+	}
+}
+
+func TestJSONFormatterBatch(t *testing.T) {
+	var buf bytes.Buffer
+	f, _ := NewFormatter(&buf, "json", FormatOptions{})
+	f.Result(Report{Label: "lint", Status: StatusPass})
+	f.Result(Report{Label: "test", Status: StatusFail, ExitCode: 1, Output: []byte("boom\n"), TotalLines: 40, ShownLines: 1, Elided: []filter.Range{{Start: 1, End: 39}}})
+	f.Summary(Summary{Passed: 1, Total: 2})
+	f.Close()
+
+	var got struct {
+		Results []struct {
+			Label      string `json:"label"`
+			Output     string `json:"output"`
+			Truncation struct {
+				Truncated bool           `json:"truncated"`
+				Elided    []filter.Range `json:"elided"`
+			} `json:"truncation"`
+		} `json:"results"`
+		Summary struct {
+			Status string `json:"status"`
+			Passed int    `json:"passed"`
+			Total  int    `json:"total"`
+		} `json:"summary"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(got.Results) != 2 || got.Results[1].Output != "boom" || !got.Results[1].Truncation.Truncated || len(got.Results[1].Truncation.Elided) != 1 {
+		t.Errorf("unexpected results: %+v", got.Results)
+	}
+	if got.Summary.Status != "fail" || got.Summary.Passed != 1 || got.Summary.Total != 2 {
+		t.Errorf("unexpected summary: %+v", got.Summary)
+	}
+}
+
+func TestJSONFormatterSkippedHasNoExitCode(t *testing.T) {
+	var buf bytes.Buffer
+	f, _ := NewFormatter(&buf, "ndjson", FormatOptions{})
+	f.Result(Report{Label: "test", Status: StatusSkipped, SkipReason: "skipped: build failed"})
+	f.Result(Report{Label: "lint", Status: StatusPass, Cached: true})
+	f.Result(Report{Label: "vet", Status: StatusPass})
+
+	var codes []any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var got map[string]any
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, line)
+		}
+		code, ok := got["exit_code"]
+		if !ok {
+			t.Fatalf("expected an exit_code key: %s", line)
+		}
+		codes = append(codes, code)
+	}
+	if codes[0] != nil || codes[1] != nil || codes[2] != float64(0) {
+		t.Errorf("expected null, null, 0; got %v", codes)
+	}
+}
+
+func TestNDJSONFormatterStreams(t *testing.T) {
+	var buf bytes.Buffer
+	f, _ := NewFormatter(&buf, "ndjson", FormatOptions{})
+	f.Result(Report{Label: "lint", Status: StatusPass})
+	if !strings.HasSuffix(buf.String(), "}\n") {
+		t.Fatalf("expected result written immediately, got: %q", buf.String())
+	}
+	f.Result(Report{Label: "test", Status: StatusSkipped, SkipReason: "skipped: build failed"})
+	f.Summary(Summary{Passed: 1, Total: 2, Stopped: true})
+	f.Close()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d: %q", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Errorf("invalid JSON line: %q", line)
+		}
+	}
+	if !strings.Contains(lines[2], `"type":"summary"`) {
+		t.Errorf("expected summary last, got: %q", lines[2])
+	}
+}
+
+func TestNewFormatterUnknown(t *testing.T) {
+	if _, err := NewFormatter(&bytes.Buffer{}, "xml", FormatOptions{}); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
+
+func TestTextFormatterStoppedSummary(t *testing.T) {
+	var buf bytes.Buffer
+	f, _ := NewFormatter(&buf, "text", FormatOptions{})
+	f.Summary(Summary{Passed: 0, Total: 2, Stopped: true})
+	if buf.Len() != 0 {
+		t.Errorf("expected no summary for stopped batch, got: %q", buf.String())
+	}
+}
+
+func TestJSONFormatterFirstFailure(t *testing.T) {
+	var buf bytes.Buffer
+	f, _ := NewFormatter(&buf, "json", FormatOptions{})
+	f.Result(Report{
+		Label:        "test",
+		Status:       StatusWarn,
+		Attempt:      2,
+		Attempts:     3,
+		FirstFailure: &Failure{ExitCode: 1, Output: []byte("ECONNRESET\n")},
+	})
+	f.Close()
+
+	var got struct {
+		Attempt      int `json:"attempt"`
+		Attempts     int `json:"attempts"`
+		FirstFailure struct {
+			ExitCode int    `json:"exit_code"`
+			Output   string `json:"output"`
+		} `json:"first_failure"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if got.Attempt != 2 || got.Attempts != 3 || got.FirstFailure.ExitCode != 1 || got.FirstFailure.Output != "ECONNRESET" {
+		t.Errorf("unexpected result: %+v", got)
+	}
+}
@@ -0,0 +1,140 @@
+package output
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// junitFormatter collects results and writes them as a JUnit XML report
+// to path on Close.
+type junitFormatter struct {
+	path    string
+	started time.Time
+	cases   []junitTestCase
+}
+
+// NewJUnitFormatter returns a formatter that writes a JUnit XML report to path.
+func NewJUnitFormatter(path string) Formatter {
+	return &junitFormatter{path: path, started: time.Now()}
+}
+
+func (f *junitFormatter) Result(r Report) {
+	tc := junitTestCase{
+		Name:      r.Label,
+		Classname: "hush",
+		Time:      junitSeconds(r.Duration),
+	}
+	output := strings.TrimSuffix(string(r.Output), "\n")
+	switch r.Status {
+	case StatusFail:
+		tc.Failure = &junitFailure{
+			Message: fmt.Sprintf("exit code %d", r.ExitCode),
+			Type:    "failure",
+			Text:    output,
+		}
+	case StatusTimeout:
+		tc.Failure = &junitFailure{
+			Message: "timed out after " + formatDuration(r.Timeout),
+			Type:    "timeout",
+			Text:    output,
+		}
+	case StatusSkipped:
+		tc.Skipped = &junitSkipped{Message: r.SkipReason}
+	case StatusWarn:
+		var out []string
+		if r.FirstFailure != nil {
+			out = append(out, fmt.Sprintf("flaky: attempt 1/%d failed with exit code %d", r.Attempts, r.FirstFailure.ExitCode))
+			if len(r.FirstFailure.Output) > 0 {
+				out = append(out, strings.TrimSuffix(string(r.FirstFailure.Output), "\n"))
+			}
+		}
+		if len(r.Warnings) > 0 {
+			out = append(out, strings.TrimSuffix(string(r.Warnings), "\n"))
+		}
+		tc.SystemOut = strings.Join(out, "\n")
+	}
+	f.cases = append(f.cases, tc)
+}
+
+// Summary is a no-op; the report totals are derived from the test cases.
+func (f *junitFormatter) Summary(Summary) {}
+
+func (f *junitFormatter) Close() error {
+	suite := junitTestSuite{
+		Name:      "hush",
+		Tests:     len(f.cases),
+		Time:      junitSeconds(time.Since(f.started)),
+		Timestamp: f.started.UTC().Format(time.RFC3339),
+		Cases:     f.cases,
+	}
+	for _, tc := range f.cases {
+		if tc.Failure != nil {
+			suite.Failures++
+		}
+		if tc.Skipped != nil {
+			suite.Skipped++
+		}
+	}
+	doc := junitTestSuites{
+		Name:     suite.Name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append([]byte(xml.Header), data...)
+	data = append(data, '\n')
+	return os.WriteFile(f.path, data, 0o644)
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,56 @@
+package output
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJUnitFormatter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.xml")
+	f := NewJUnitFormatter(path)
+	f.Result(Report{Label: "lint", Command: "ruff check .", Status: StatusPass, Duration: 1200 * time.Millisecond})
+	f.Result(Report{Label: "types", Status: StatusWarn, WarningCount: 1, Warnings: []byte("warning TS1000\n")})
+	f.Result(Report{Label: "test", Command: "pytest -x", Status: StatusFail, ExitCode: 1, Output: []byte("E  assert 1 == 2\n")})
+	f.Result(Report{Label: "e2e", Status: StatusTimeout, ExitCode: 124, Timeout: 5 * time.Minute})
+	f.Result(Report{Label: "deploy", Status: StatusSkipped, SkipReason: "skipped: test failed"})
+	f.Summary(Summary{Passed: 2, Total: 5})
+	if err := f.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("report not written: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "<?xml") {
+		t.Errorf("expected XML header, got: %q", data[:20])
+	}
+
+	var doc junitTestSuites
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid XML: %v", err)
+	}
+	if doc.Tests != 5 || doc.Failures != 2 || doc.Skipped != 1 {
+		t.Errorf("unexpected totals: tests=%d failures=%d skipped=%d", doc.Tests, doc.Failures, doc.Skipped)
+	}
+	cases := doc.Suites[0].Cases
+	if cases[0].Time != "1.200" || cases[0].Failure != nil {
+		t.Errorf("unexpected passing case: %+v", cases[0])
+	}
+	if cases[1].SystemOut != "warning TS1000" {
+		t.Errorf("expected warnings in system-out, got %q", cases[1].SystemOut)
+	}
+	if cases[2].Failure == nil || cases[2].Failure.Text != "E  assert 1 == 2" {
+		t.Errorf("expected failure output, got %+v", cases[2].Failure)
+	}
+	if cases[3].Failure == nil || cases[3].Failure.Type != "timeout" {
+		t.Errorf("expected timeout failure, got %+v", cases[3].Failure)
+	}
+	if cases[4].Skipped == nil || cases[4].Skipped.Message != "skipped: test failed" {
+		t.Errorf("expected skipped case, got %+v", cases[4].Skipped)
+	}
+}
@@ -0,0 +1,154 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alfranz/hush/internal/filter"
+)
+
+const (
+	// LiveHeartbeat is how often a non-interactive live display reports
+	// that the command is still running.
+	LiveHeartbeat = 30 * time.Second
+	// liveTail is how many recent lines the interactive display shows.
+	liveTail = 5
+	// liveRefresh is the redraw interval of the interactive display.
+	liveRefresh = 100 * time.Millisecond
+)
+
+var spinner = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Live shows progress while a command runs. On a terminal it draws a
+// spinner with the last few output lines and erases them when stopped;
+// otherwise it writes a heartbeat line every LiveHeartbeat.
+type Live struct {
+	w        io.Writer
+	label    string
+	tty      bool
+	interval time.Duration
+	start    time.Time
+
+	mu     sync.Mutex
+	lines  int
+	recent []string
+	drawn  int
+	frame  int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewLive starts a live display for label on w. tty selects the
+// interactive display.
+func NewLive(w io.Writer, label string, tty bool) *Live {
+	interval := LiveHeartbeat
+	if tty {
+		interval = liveRefresh
+	}
+	return newLive(w, label, tty, interval)
+}
+
+func newLive(w io.Writer, label string, tty bool, interval time.Duration) *Live {
+	l := &Live{
+		w:        w,
+		label:    label,
+		tty:      tty,
+		interval: interval,
+		start:    time.Now(),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go l.loop()
+	return l
+}
+
+// Line records a line of command output.
+func (l *Live) Line(line []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines++
+	if l.tty {
+		l.recent = append(l.recent, string(filter.StripANSI(line)))
+		if len(l.recent) > liveTail {
+			l.recent = l.recent[1:]
+		}
+	}
+}
+
+// Stop ends the display, erasing the interactive region.
+func (l *Live) Stop() {
+	close(l.stop)
+	<-l.done
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clear()
+}
+
+func (l *Live) loop() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			l.mu.Lock()
+			if l.tty {
+				l.draw()
+			} else {
+				fmt.Fprintf(l.w, "… %s running (%s)\n", l.label, l.progress())
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+func (l *Live) progress() string {
+	elapsed := time.Since(l.start).Round(time.Second)
+	return fmt.Sprintf("%s, %s", formatDuration(elapsed), plural(l.lines, "line"))
+}
+
+// draw redraws the spinner and recent lines in place.
+func (l *Live) draw() {
+	l.clear()
+	width := terminalWidth()
+	fmt.Fprintf(l.w, "%s %s (%s)\n", spinner[l.frame%len(spinner)], l.label, l.progress())
+	for _, line := range l.recent {
+		fmt.Fprintf(l.w, "  %s\n", truncate(line, width-2))
+	}
+	l.frame++
+	l.drawn = 1 + len(l.recent)
+}
+
+// clear erases the lines drawn last.
+func (l *Live) clear() {
+	if l.drawn > 0 {
+		fmt.Fprintf(l.w, "\x1b[%dF\x1b[J", l.drawn)
+		l.drawn = 0
+	}
+}
+
+// truncate shortens s to width columns so redrawn lines never wrap.
+func truncate(s string, width int) string {
+	s = strings.ReplaceAll(s, "\t", "    ")
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	return string(r[:max(width-1, 0)]) + "…"
+}
+
+// terminalWidth returns $COLUMNS, or 80.
+func terminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return 80
+}
@@ -0,0 +1,49 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLiveHeartbeat(t *testing.T) {
+	var buf bytes.Buffer
+	l := newLive(&buf, "pytest", false, 20*time.Millisecond)
+	for range 1234 {
+		l.Line([]byte("tests/test_api.py::test_get PASSED"))
+	}
+	time.Sleep(50 * time.Millisecond)
+	l.Stop()
+
+	first, _, _ := strings.Cut(buf.String(), "\n")
+	if first != "… pytest running (0s, 1,234 lines)" {
+		t.Errorf("unexpected heartbeat: %q", first)
+	}
+}
+
+func TestLiveTTYErasesRegion(t *testing.T) {
+	var buf bytes.Buffer
+	l := newLive(&buf, "make", true, 10*time.Millisecond)
+	l.Line([]byte("compiling a.c"))
+	l.Line([]byte("compiling b.c"))
+	time.Sleep(30 * time.Millisecond)
+	l.Stop()
+
+	got := buf.String()
+	if !strings.Contains(got, "make (0s, 2 lines)\n  compiling a.c\n  compiling b.c\n") {
+		t.Errorf("expected spinner with recent lines, got: %q", got)
+	}
+	if !strings.HasSuffix(got, "\x1b[3F\x1b[J") {
+		t.Errorf("expected region erased on stop, got: %q", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("abcdef", 4); got != "abc…" {
+		t.Errorf("unexpected truncation: %q", got)
+	}
+	if got := truncate("abc", 4); got != "abc" {
+		t.Errorf("expected short line unchanged, got: %q", got)
+	}
+}
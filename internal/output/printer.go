@@ -5,37 +5,84 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
+
+	"github.com/alfranz/hush/internal/filter"
 )
 
-func PrintResult(w io.Writer, label string, exitCode int, filteredOutput []byte, warningCount int, warningOutput []byte) {
-	if exitCode == 0 {
-		if warningCount > 0 {
-			printWarningSuccess(w, label, warningCount, warningOutput)
-			return
+// printText renders r as a summary line followed by any relevant output.
+// With durations set, the run time is appended to the summary line.
+func printText(w io.Writer, r Report, durations bool) {
+	var notes []string
+	elapsed := func() {
+		if durations {
+			notes = append(notes, formatElapsed(r.Duration))
 		}
-		printSuccess(w, label)
-	} else {
-		printFailure(w, label, filteredOutput)
 	}
-}
 
-func printSuccess(w io.Writer, label string) {
-	fmt.Fprintf(w, "✓ %s\n", label)
+	switch r.Status {
+	case StatusSkipped:
+		printSummaryLine(w, "⊘", r.Label, []string{r.SkipReason})
+	case StatusTimeout:
+		printSummaryLine(w, "⏱", r.Label, []string{"timed out after " + formatDuration(r.Timeout)})
+		printFailure(w, r)
+	case StatusFail:
+		elapsed()
+		printSummaryLine(w, "✗", r.Label, notes)
+		printFailure(w, r)
+	case StatusWarn:
+		if r.Flaky() {
+			notes = append(notes, fmt.Sprintf("flaky: passed on attempt %d/%d", r.Attempt, r.Attempts))
+		}
+		if r.WarningCount > 0 {
+			notes = append(notes, plural(r.WarningCount, "warning"))
+		}
+		if r.Slow > 0 {
+			notes = append(notes, fmt.Sprintf("slow: %s > %s", formatElapsed(r.Duration), formatDuration(r.Slow)))
+		} else {
+			elapsed()
+		}
+		printSummaryLine(w, "⚠", r.Label, notes)
+		printWarnings(w, r.WarningCount, r.Warnings)
+	default:
+		if r.Cached {
+			notes = append(notes, "cached")
+		} else {
+			elapsed()
+		}
+		printSummaryLine(w, "✓", r.Label, notes)
+	}
 }
 
-func printFailure(w io.Writer, label string, output []byte) {
-	fmt.Fprintf(w, "✗ %s\n", label)
-	if len(output) > 0 {
-		fmt.Fprintf(w, "  %s\n", indentOutput(output))
+func printSummaryLine(w io.Writer, glyph, label string, notes []string) {
+	if len(notes) == 0 {
+		fmt.Fprintf(w, "%s %s\n", glyph, label)
+		return
 	}
+	fmt.Fprintf(w, "%s %s (%s)\n", glyph, label, strings.Join(notes, ", "))
 }
 
-func printWarningSuccess(w io.Writer, label string, warningCount int, output []byte) {
-	suffix := "warnings"
-	if warningCount == 1 {
-		suffix = "warning"
+// printFailure prints the filtered output of a failed command, noting how
+// many lines filtering left out.
+func printFailure(w io.Writer, r Report) {
+	if len(r.Output) > 0 {
+		fmt.Fprintf(w, "  %s\n", indentOutput(r.Output))
+	}
+	// Markers in the output already count what they stand for; repeat the
+	// count only if other lines are missing too.
+	omitted := r.TotalLines - r.ShownLines
+	counted := omitted > r.MarkedLines
+	switch {
+	case counted && r.Recall != "":
+		fmt.Fprintf(w, "  … %s omitted (use %s)\n", plural(omitted, "line"), r.Recall)
+	case counted:
+		fmt.Fprintf(w, "  … %s omitted\n", plural(omitted, "line"))
+	case omitted > 0 && r.Recall != "":
+		fmt.Fprintf(w, "  … use %s for the full output\n", r.Recall)
 	}
-	fmt.Fprintf(w, "⚠ %s (%d %s)\n", label, warningCount, suffix)
+}
+
+func printWarnings(w io.Writer, warningCount int, output []byte) {
 	if len(output) == 0 {
 		return
 	}
@@ -47,12 +94,48 @@ func printWarningSuccess(w io.Writer, label string, warningCount int, output []b
 	}
 }
 
-func PrintBatchSummary(w io.Writer, passed, total int) {
-	if passed == total {
-		fmt.Fprintf(w, "✓ %d/%d checks passed\n", passed, total)
-	} else {
-		fmt.Fprintf(w, "✗ %d/%d checks passed\n", passed, total)
+func printBatchSummary(w io.Writer, s Summary, durations bool) {
+	glyph := "✓"
+	if s.Passed != s.Total {
+		glyph = "✗"
+	}
+	var notes []string
+	if durations {
+		notes = append(notes, formatElapsed(s.Duration))
+	}
+	printSummaryLine(w, glyph, fmt.Sprintf("%d/%d checks passed", s.Passed, s.Total), notes)
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, word)
+	}
+	return fmt.Sprintf("%s %ss", filter.GroupThousands(n), word)
+}
+
+// formatDuration renders d compactly, dropping zero trailing units
+// ("5m" rather than "5m0s").
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// formatElapsed renders a measured run time at a precision suited to its
+// magnitude: "120ms", "4.2s", "2m10s".
+func formatElapsed(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%.1fs", d.Seconds())
 	}
+	return formatDuration(d.Round(time.Second))
 }
 
 func indentOutput(b []byte) string {
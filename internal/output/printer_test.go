@@ -4,76 +4,125 @@ import (
 	"bytes"
 	"strings"
 	"testing"
+	"time"
 )
 
-func TestPrintResultSuccess(t *testing.T) {
+func TestTextFormatterWarnings(t *testing.T) {
 	var buf bytes.Buffer
-	PrintResult(&buf, "echo", 0, nil, 0, nil)
+	f, _ := NewFormatter(&buf, "text", FormatOptions{})
+	f.Result(Report{Label: "types", Status: StatusWarn, WarningCount: 3, Warnings: []byte("w1\nw2")})
 	got := buf.String()
-	if got != "✓ echo\n" {
-		t.Errorf("expected '✓ echo\\n', got: %q", got)
+	if !strings.Contains(got, "⚠ types (3 warnings)") {
+		t.Errorf("expected warning marker, got: %q", got)
+	}
+	if !strings.Contains(got, "  w1") || !strings.Contains(got, "  w2") {
+		t.Errorf("expected warning details, got: %q", got)
+	}
+	if !strings.Contains(got, "... and 1 more") {
+		t.Errorf("expected hidden warning count, got: %q", got)
 	}
 }
 
-func TestPrintResultFailure(t *testing.T) {
+func TestTextFormatterTimeout(t *testing.T) {
 	var buf bytes.Buffer
-	PrintResult(&buf, "test", 1, []byte("FAIL: something broke\n"), 0, nil)
+	f, _ := NewFormatter(&buf, "text", FormatOptions{})
+	f.Result(Report{Label: "pytest", Status: StatusTimeout, Timeout: 5 * time.Minute, Output: []byte("collecting...\n")})
 	got := buf.String()
-	if !strings.Contains(got, "✗ test") {
-		t.Errorf("expected failure marker, got: %q", got)
+	if !strings.HasPrefix(got, "⏱ pytest (timed out after 5m)\n") {
+		t.Errorf("unexpected timeout line, got: %q", got)
 	}
-	if !strings.Contains(got, "FAIL: something broke") {
+	if !strings.Contains(got, "  collecting...") {
 		t.Errorf("expected output, got: %q", got)
 	}
 }
 
-func TestPrintResultNoDuration(t *testing.T) {
+func TestTextFormatterSkipped(t *testing.T) {
 	var buf bytes.Buffer
-	PrintResult(&buf, "echo", 0, nil, 0, nil)
-	got := buf.String()
-	if strings.Contains(got, "(") {
-		t.Errorf("expected no duration, got: %q", got)
+	f, _ := NewFormatter(&buf, "text", FormatOptions{})
+	f.Result(Report{Label: "test", Status: StatusSkipped, SkipReason: "skipped: build failed"})
+	if got := buf.String(); got != "⊘ test (skipped: build failed)\n" {
+		t.Errorf("unexpected skipped line, got: %q", got)
 	}
 }
 
-func TestPrintResultNoANSI(t *testing.T) {
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{30 * time.Second, "30s"},
+		{5 * time.Minute, "5m"},
+		{90 * time.Second, "1m30s"},
+		{2 * time.Hour, "2h"},
+	}
+	for _, tt := range tests {
+		if got := formatDuration(tt.d); got != tt.want {
+			t.Errorf("formatDuration(%s) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestTextFormatterDurations(t *testing.T) {
 	var buf bytes.Buffer
-	PrintResult(&buf, "echo", 0, nil, 0, nil)
-	got := buf.String()
-	if strings.Contains(got, "\x1b[") {
-		t.Errorf("expected no ANSI codes, got: %q", got)
+	f, _ := NewFormatter(&buf, "text", FormatOptions{Durations: true})
+	f.Result(Report{Label: "pytest", Status: StatusPass, Duration: 4200 * time.Millisecond})
+	f.Result(Report{Label: "lint", Status: StatusFail, ExitCode: 1, Duration: 120 * time.Millisecond})
+	f.Summary(Summary{Passed: 1, Total: 2, Duration: 130 * time.Second})
+	want := "✓ pytest (4.2s)\n✗ lint (120ms)\n✗ 1/2 checks passed (2m10s)\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\n got: %q\nwant: %q", got, want)
 	}
 }
 
-func TestPrintResultWarningSuccess(t *testing.T) {
+func TestTextFormatterSlow(t *testing.T) {
 	var buf bytes.Buffer
-	PrintResult(&buf, "types", 0, nil, 3, []byte("w1\nw2"))
-	got := buf.String()
-	if !strings.Contains(got, "⚠ types (3 warnings)") {
-		t.Errorf("expected warning marker, got: %q", got)
+	f, _ := NewFormatter(&buf, "text", FormatOptions{})
+	f.Result(Report{Label: "pytest", Status: StatusWarn, Duration: 4200 * time.Millisecond, Slow: 3 * time.Second})
+	if got := buf.String(); got != "⚠ pytest (slow: 4.2s > 3s)\n" {
+		t.Errorf("unexpected output: %q", got)
 	}
-	if !strings.Contains(got, "  w1") || !strings.Contains(got, "  w2") {
-		t.Errorf("expected warning details, got: %q", got)
+}
+
+func TestTextFormatterFlaky(t *testing.T) {
+	var buf bytes.Buffer
+	f, _ := NewFormatter(&buf, "text", FormatOptions{})
+	f.Result(Report{Label: "test", Status: StatusWarn, Attempt: 2, Attempts: 3})
+	if got := buf.String(); got != "⚠ test (flaky: passed on attempt 2/3)\n" {
+		t.Errorf("unexpected output: %q", got)
 	}
-	if !strings.Contains(got, "... and 1 more") {
-		t.Errorf("expected hidden warning count, got: %q", got)
+}
+
+func TestTextFormatterCached(t *testing.T) {
+	var buf bytes.Buffer
+	f, _ := NewFormatter(&buf, "text", FormatOptions{Durations: true})
+	f.Result(Report{Label: "lint", Status: StatusPass, Cached: true})
+	if got := buf.String(); got != "✓ lint (cached)\n" {
+		t.Errorf("unexpected output: %q", got)
 	}
 }
 
-func TestPrintBatchSummaryAllPass(t *testing.T) {
+func TestPrintFailureOmittedFooter(t *testing.T) {
 	var buf bytes.Buffer
-	PrintBatchSummary(&buf, 3, 3)
-	got := buf.String()
-	if got != "✓ 3/3 checks passed\n" {
-		t.Errorf("expected '✓ 3/3 checks passed\\n', got: %q", got)
+	printText(&buf, Report{Label: "test", Status: StatusFail, ExitCode: 1, Output: []byte("boom"), TotalLines: 413, ShownLines: 1}, false)
+	want := "✗ test\n  boom\n  … 412 lines omitted\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\n got: %q\nwant: %q", got, want)
 	}
 }
 
-func TestPrintBatchSummaryWithFailure(t *testing.T) {
+func TestPrintFailureMarkedGap(t *testing.T) {
 	var buf bytes.Buffer
-	PrintBatchSummary(&buf, 1, 3)
-	got := buf.String()
-	if got != "✗ 1/3 checks passed\n" {
-		t.Errorf("expected '✗ 1/3 checks passed\\n', got: %q", got)
+	printText(&buf, Report{Label: "test", Status: StatusFail, ExitCode: 1, Output: []byte("a\n… 40 lines omitted …\nz"), TotalLines: 42, ShownLines: 2, MarkedLines: 40, Recall: "hush last test --full"}, false)
+	want := "✗ test\n  a\n  … 40 lines omitted …\n  z\n  … use hush last test --full for the full output\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestPrintFailureRecallHint(t *testing.T) {
+	var buf bytes.Buffer
+	printText(&buf, Report{Label: "test", Status: StatusFail, ExitCode: 1, Output: []byte("boom"), TotalLines: 3, ShownLines: 1, Recall: "hush last test --full"}, false)
+	if got := buf.String(); !strings.HasSuffix(got, "  … 2 lines omitted (use hush last test --full)\n") {
+		t.Errorf("expected recall hint, got: %q", got)
 	}
 }
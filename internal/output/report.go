@@ -0,0 +1,159 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/alfranz/hush/internal/filter"
+)
+
+// Status classifies a command result.
+type Status string
+
+const (
+	StatusPass    Status = "pass"
+	StatusFail    Status = "fail"
+	StatusWarn    Status = "warn"
+	StatusTimeout Status = "timeout"
+	StatusSkipped Status = "skipped"
+)
+
+// Report is a command result after filtering, ready to be printed.
+type Report struct {
+	Label    string
+	Command  string
+	ExitCode int
+	Duration time.Duration
+	Status   Status
+	// Output is the filtered command output.
+	Output       []byte
+	WarningCount int
+	// Warnings holds the warning lines selected for display.
+	Warnings []byte
+	// TotalLines and ShownLines describe how much of the output survived
+	// filtering; Elided lists the removed line ranges. MarkedLines is how
+	// many of the removed lines markers in Output already count.
+	TotalLines  int
+	ShownLines  int
+	MarkedLines int
+	Elided      []filter.Range
+	// Recall is a command that prints the full output, if it was recorded.
+	Recall     string
+	Timeout    time.Duration
+	SkipReason string
+	// Slow is the threshold a passing command exceeded, turning it into a
+	// warning; zero if it was not slow.
+	Slow time.Duration
+	// Attempt is the attempt that produced the result, out of at most
+	// Attempts. A command that passed on a retry is flaky.
+	Attempt  int
+	Attempts int
+	// FirstFailure is the first failed attempt of a flaky command.
+	FirstFailure *Failure
+	// Cached is set when the command did not run because it already
+	// passed with the same inputs.
+	Cached bool
+}
+
+// Flaky reports whether the command passed only after failing.
+func (r Report) Flaky() bool {
+	return r.Attempt > 1 && r.ExitCode == 0
+}
+
+// Failure is a failed attempt of a command that was retried.
+type Failure struct {
+	ExitCode int
+	// Output is the filtered output of the attempt.
+	Output []byte
+}
+
+// Summary describes a finished batch.
+type Summary struct {
+	Passed int
+	Total  int
+	// Stopped is set when the batch ended early on a failure.
+	Stopped bool
+	// Duration is the wall time of the whole batch.
+	Duration time.Duration
+}
+
+// FormatOptions tunes how reports are rendered.
+type FormatOptions struct {
+	// Durations appends run times to text summary lines.
+	Durations bool
+}
+
+// Formatter renders reports in a particular output format.
+type Formatter interface {
+	Result(r Report)
+	Summary(s Summary)
+	// Close flushes anything the formatter buffered.
+	Close() error
+}
+
+// NewFormatter returns a formatter writing the named format to w.
+func NewFormatter(w io.Writer, format string, opts FormatOptions) (Formatter, error) {
+	switch format {
+	case "", "text":
+		return &textFormatter{w: w, durations: opts.Durations}, nil
+	case "json":
+		return &jsonFormatter{w: w}, nil
+	case "ndjson":
+		return &jsonFormatter{w: w, stream: true}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q (want text, json or ndjson)", format)
+}
+
+// textFormatter prints the compact ✓/✗ lines.
+type textFormatter struct {
+	w         io.Writer
+	durations bool
+}
+
+func (f *textFormatter) Result(r Report) {
+	printText(f.w, r, f.durations)
+}
+
+func (f *textFormatter) Summary(s Summary) {
+	// A batch that stopped early has already shown its failure
+	if s.Stopped {
+		return
+	}
+	printBatchSummary(f.w, s, f.durations)
+}
+
+func (f *textFormatter) Close() error { return nil }
+
+// multiFormatter sends every report to several formatters.
+type multiFormatter []Formatter
+
+// Tee returns a formatter that forwards to each of formatters in turn.
+func Tee(formatters ...Formatter) Formatter {
+	if len(formatters) == 1 {
+		return formatters[0]
+	}
+	return multiFormatter(formatters)
+}
+
+func (m multiFormatter) Result(r Report) {
+	for _, f := range m {
+		f.Result(r)
+	}
+}
+
+func (m multiFormatter) Summary(s Summary) {
+	for _, f := range m {
+		f.Summary(s)
+	}
+}
+
+func (m multiFormatter) Close() error {
+	var firstErr error
+	for _, f := range m {
+		if err := f.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,54 @@
+package output
+
+import (
+	"fmt"
+	"io"
+)
+
+var statusGlyphs = map[Status]string{
+	StatusPass:    "✓",
+	StatusFail:    "✗",
+	StatusWarn:    "⚠",
+	StatusTimeout: "⏱",
+	StatusSkipped: "⊘",
+}
+
+// transitionFormatter reports repeated runs of the same commands by their
+// changes: a result is printed as "✗ → ✓ label" when its status differs
+// from the previous run, and on its first run only if it did not pass.
+type transitionFormatter struct {
+	w         io.Writer
+	durations bool
+	last      map[string]Status
+	// summarized is set once the first summary has been seen.
+	summarized bool
+}
+
+// NewTransitionFormatter returns a text formatter for commands that are
+// run again and again, as by hush watch.
+func NewTransitionFormatter(w io.Writer, opts FormatOptions) Formatter {
+	return &transitionFormatter{w: w, durations: opts.Durations, last: make(map[string]Status)}
+}
+
+func (f *transitionFormatter) Result(r Report) {
+	prev, seen := f.last[r.Label]
+	f.last[r.Label] = r.Status
+	switch {
+	case !seen && r.Status == StatusPass, seen && prev == r.Status:
+		return
+	case seen:
+		fmt.Fprintf(f.w, "%s → ", statusGlyphs[prev])
+	}
+	printText(f.w, r, f.durations)
+}
+
+// Summary confirms a first run in which everything passed, which would
+// otherwise print nothing; later summaries are dropped.
+func (f *transitionFormatter) Summary(s Summary) {
+	if !f.summarized && s.Passed == s.Total {
+		printBatchSummary(f.w, s, f.durations)
+	}
+	f.summarized = true
+}
+
+func (f *transitionFormatter) Close() error { return nil }
@@ -0,0 +1,39 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTransitionFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	f := NewTransitionFormatter(&buf, FormatOptions{})
+
+	// First run: only what did not pass, and a summary if all passed
+	f.Result(Report{Label: "lint", Status: StatusPass})
+	f.Result(Report{Label: "test", Status: StatusFail, ExitCode: 1, Output: []byte("boom")})
+	f.Summary(Summary{Passed: 1, Total: 2})
+	// Reruns: only transitions
+	f.Result(Report{Label: "lint", Status: StatusFail, ExitCode: 1, Output: []byte("E501")})
+	f.Result(Report{Label: "test", Status: StatusFail, ExitCode: 1, Output: []byte("boom")})
+	f.Summary(Summary{Passed: 0, Total: 2})
+	f.Result(Report{Label: "lint", Status: StatusPass})
+	f.Summary(Summary{Passed: 1, Total: 1})
+
+	want := "✗ test\n  boom\n✓ → ✗ lint\n  E501\n✗ → ✓ lint\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestTransitionFormatterAllPassed(t *testing.T) {
+	var buf bytes.Buffer
+	f := NewTransitionFormatter(&buf, FormatOptions{})
+	f.Result(Report{Label: "lint", Status: StatusPass})
+	f.Summary(Summary{Passed: 1, Total: 1})
+	f.Result(Report{Label: "lint", Status: StatusPass})
+	f.Summary(Summary{Passed: 1, Total: 1})
+	if got := buf.String(); got != "✓ 1/1 checks passed\n" {
+		t.Errorf("unexpected output: %q", got)
+	}
+}
@@ -0,0 +1,230 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/alfranz/hush/internal/filter"
+)
+
+// capture collects a command's stdout and stderr separately while also
+// keeping a copy interleaved line by line in the order lines are completed,
+// so that a line is never split by output from the other stream. Each view keeps
+// at most max bytes in memory; once the interleaved output outgrows that,
+// all of it is written to a spill file as well. If onLine is set it is
+// called with each complete line as it is written.
+type capture struct {
+	mu       sync.Mutex
+	combined boundedBuffer
+	stdout   boundedBuffer
+	stderr   boundedBuffer
+	spill    *os.File
+	spillErr error
+	onLine   func(line []byte)
+	partial  map[*boundedBuffer][]byte
+}
+
+func newCapture(max int, onLine func(line []byte)) *capture {
+	c := &capture{onLine: onLine}
+	c.combined.max, c.stdout.max, c.stderr.max = max, max, max
+	return c
+}
+
+// writer returns a writer that appends to stream and the combined view.
+func (c *capture) writer(stream *boundedBuffer) io.Writer {
+	return writerFunc(func(p []byte) (int, error) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.scanLines(stream, p)
+		return stream.Write(p)
+	})
+}
+
+// spillWrite copies p to the spill file, creating it with everything
+// captured so far when the combined view is about to start dropping data.
+func (c *capture) spillWrite(p []byte) {
+	if c.spillErr != nil {
+		return
+	}
+	if c.spill == nil {
+		if c.combined.max <= 0 || c.combined.total+int64(len(p)) <= int64(c.combined.max) {
+			return
+		}
+		if c.spill, c.spillErr = os.CreateTemp("", "hush-*.log"); c.spillErr != nil {
+			return
+		}
+		// Nothing has been dropped yet: the tail is only trimmed once
+		// more than max bytes have been written.
+		_, c.spillErr = c.spill.Write(slices.Concat(c.combined.head, c.combined.tail))
+	}
+	if c.spillErr == nil {
+		_, c.spillErr = c.spill.Write(p)
+	}
+}
+
+// scanLines adds the complete lines in p to the combined view, carrying an
+// unterminated last line over to the next write on the same stream.
+func (c *capture) scanLines(stream *boundedBuffer, p []byte) {
+	if c.partial == nil {
+		c.partial = make(map[*boundedBuffer][]byte)
+	}
+	buf := p
+	if pending := c.partial[stream]; len(pending) > 0 {
+		buf = append(pending, p...)
+	}
+	for {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			break
+		}
+		c.addLine(buf[:i+1])
+		buf = buf[i+1:]
+	}
+	c.partial[stream] = append(c.partial[stream][:0], buf...)
+}
+
+// addLine appends a line, including its line break if any, to the
+// combined view.
+func (c *capture) addLine(line []byte) {
+	c.spillWrite(line)
+	c.combined.Write(line)
+	if c.onLine != nil {
+		c.onLine(bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r")))
+	}
+}
+
+// finish adds unterminated last lines once the command has exited and
+// closes the spill file, returning its path if there is one.
+func (c *capture) finish() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, stream := range []*boundedBuffer{&c.stdout, &c.stderr} {
+		if len(c.partial[stream]) > 0 {
+			c.addLine(c.partial[stream])
+		}
+	}
+	if c.spill == nil {
+		return ""
+	}
+	if err := c.spill.Close(); err != nil || c.spillErr != nil {
+		// An incomplete spill file is worse than none
+		os.Remove(c.spill.Name())
+		return ""
+	}
+	return c.spill.Name()
+}
+
+// boundedBuffer keeps the first and last max/2 bytes written to it, cut at
+// line boundaries, and counts the lines dropped in between. max <= 0 keeps
+// everything.
+type boundedBuffer struct {
+	max      int
+	head     []byte
+	headDone bool
+	tail     []byte
+	total    int64
+	// dropped counts the bytes and lines discarded from the front of tail.
+	dropped      int64
+	droppedLines int
+	// midLine is set when the tail starts partway through a line.
+	midLine bool
+}
+
+func (b *boundedBuffer) Write(p []byte) (int, error) {
+	b.total += int64(len(p))
+	if b.max <= 0 {
+		b.head = append(b.head, p...)
+		return len(p), nil
+	}
+
+	half := b.max / 2
+	rest := p
+	if !b.headDone {
+		n := min(len(rest), half-len(b.head))
+		b.head = append(b.head, rest[:n]...)
+		rest = rest[n:]
+		if len(b.head) < half {
+			return len(p), nil
+		}
+		// End the head on a line boundary; the rest starts the tail.
+		cut := bytes.LastIndexByte(b.head, '\n') + 1
+		b.tail = append(b.tail, b.head[cut:]...)
+		b.head = b.head[:cut:cut]
+		b.headDone = true
+	}
+
+	b.tail = append(b.tail, rest...)
+	// Let the tail grow to twice its size between trims so that
+	// trimming stays cheap.
+	if len(b.tail) > 2*half {
+		b.trim()
+	}
+	return len(p), nil
+}
+
+// trim drops the front of the tail so that max/2 bytes remain.
+func (b *boundedBuffer) trim() {
+	drop := len(b.tail) - b.max/2
+	if b.max <= 0 || drop <= 0 {
+		return
+	}
+	b.dropped += int64(drop)
+	b.droppedLines += bytes.Count(b.tail[:drop], []byte("\n"))
+	b.midLine = b.tail[drop-1] != '\n'
+	b.tail = append(b.tail[:0], b.tail[drop:]...)
+}
+
+// Bytes returns the captured output. If data was dropped, a marker line
+// stands in for the missing lines.
+func (b *boundedBuffer) Bytes() []byte {
+	b.trim()
+	if b.dropped == 0 {
+		return append(append([]byte(nil), b.head...), b.tail...)
+	}
+	tail, lines := b.keptTail()
+	out := append([]byte(nil), b.head...)
+	out = fmt.Appendf(out, "… %s lines not captured …\n", filter.GroupThousands(lines))
+	return append(out, tail...)
+}
+
+// Gap describes where Bytes left lines out.
+func (b *boundedBuffer) Gap() Gap {
+	b.trim()
+	if b.dropped == 0 {
+		return Gap{}
+	}
+	_, lines := b.keptTail()
+	return Gap{Line: bytes.Count(b.head, []byte("\n")) + 1, Lines: lines}
+}
+
+// keptTail returns the tail from its first whole line, and the number of
+// lines dropped before it.
+func (b *boundedBuffer) keptTail() ([]byte, int) {
+	if !b.midLine {
+		return b.tail, b.droppedLines
+	}
+	i := bytes.IndexByte(b.tail, '\n')
+	if i < 0 {
+		return nil, b.droppedLines + 1
+	}
+	return b.tail[i+1:], b.droppedLines + 1
+}
+
+// Gap marks lines of output that were not kept in memory.
+type Gap struct {
+	// Line is the 1-based line of the marker standing in for the
+	// missing lines; zero if nothing is missing.
+	Line int
+	// Lines is the number of lines the marker replaces.
+	Lines int
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBoundedBufferKeepsEverythingUnderLimit(t *testing.T) {
+	b := boundedBuffer{max: 64}
+	b.Write([]byte("one\ntwo\n"))
+	if got := string(b.Bytes()); got != "one\ntwo\n" {
+		t.Errorf("unexpected output: %q", got)
+	}
+	if b.Gap() != (Gap{}) {
+		t.Errorf("expected no gap, got %+v", b.Gap())
+	}
+}
+
+func TestBoundedBufferKeepsHeadAndTail(t *testing.T) {
+	b := boundedBuffer{max: 40}
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintf(&b, "line %03d\n", i)
+	}
+	got := string(b.Bytes())
+	gap := b.Gap()
+
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	if lines[0] != "line 001" || lines[len(lines)-1] != "line 100" {
+		t.Errorf("expected head and tail kept, got: %q", got)
+	}
+	marker := lines[gap.Line-1]
+	if marker != fmt.Sprintf("… %d lines not captured …", gap.Lines) {
+		t.Errorf("expected marker at line %d, got %q", gap.Line, marker)
+	}
+	if kept := len(lines) - 1; kept+gap.Lines != 100 {
+		t.Errorf("expected kept + missing = 100 lines, got %d + %d", kept, gap.Lines)
+	}
+}
+
+func TestRunSpillsLargeOutput(t *testing.T) {
+	r, err := Run(t.Context(), Options{Command: "seq 1 10000", MaxCapture: 1024})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Close()
+
+	if len(r.Output) > 1100 {
+		t.Errorf("expected bounded output, got %d bytes", len(r.Output))
+	}
+	if r.OutputGap.Lines == 0 || r.StdoutGap != r.OutputGap {
+		t.Errorf("expected matching gaps, got %+v and %+v", r.OutputGap, r.StdoutGap)
+	}
+	full, err := os.ReadFile(r.Spill)
+	if err != nil {
+		t.Fatalf("expected spill file: %v", err)
+	}
+	if n := strings.Count(string(full), "\n"); n != 10000 {
+		t.Errorf("expected complete spill, got %d lines", n)
+	}
+
+	spill := r.Spill
+	r.Close()
+	if _, err := os.Stat(spill); !os.IsNotExist(err) {
+		t.Errorf("expected spill removed, got %v", err)
+	}
+}
+
+func TestRunNoSpillUnderLimit(t *testing.T) {
+	r, err := Run(t.Context(), Options{Command: "seq 1 10"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Spill != "" || r.OutputGap != (Gap{}) {
+		t.Errorf("expected nothing spilled, got %q %+v", r.Spill, r.OutputGap)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build !windows
+
+package runner
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// setProcessGroup starts cmd in its own process group so that cancellation
+// reaches grandchildren spawned by the shell. On cancel the group gets
+// SIGTERM, then SIGKILL once the grace period has elapsed.
+func setProcessGroup(cmd *exec.Cmd, grace time.Duration) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		pgid := -cmd.Process.Pid
+		if err := syscall.Kill(pgid, syscall.SIGTERM); err != nil {
+			return err
+		}
+		time.AfterFunc(grace, func() {
+			_ = syscall.Kill(pgid, syscall.SIGKILL)
+		})
+		return nil
+	}
+	// Grandchildren may hold the output pipe open after the shell exits.
+	cmd.WaitDelay = grace + time.Second
+}
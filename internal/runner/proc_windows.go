@@ -0,0 +1,14 @@
+//go:build windows
+
+package runner
+
+import (
+	"os/exec"
+	"time"
+)
+
+// setProcessGroup relies on the default kill-on-cancel behaviour; Windows
+// has no process groups to signal.
+func setProcessGroup(cmd *exec.Cmd, grace time.Duration) {
+	cmd.WaitDelay = grace
+}
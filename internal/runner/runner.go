@@ -3,64 +3,289 @@ package runner
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
 	"os/exec"
+	"slices"
 	"strings"
 	"time"
+
+	"github.com/alfranz/hush/internal/filter"
 )
 
+// TimeoutExitCode is the exit code reported for timed-out commands,
+// following the timeout(1) convention.
+const TimeoutExitCode = 124
+
+// DefaultGracePeriod is how long a timed-out process group gets between
+// SIGTERM and SIGKILL.
+const DefaultGracePeriod = 5 * time.Second
+
+// ShellNone is the Options.Shell value that runs commands without a shell.
+const ShellNone = "none"
+
+// NotFoundExitCode is the exit code reported when the program to run
+// without a shell cannot be found, as a shell would.
+const NotFoundExitCode = 127
+
+// DefaultMaxCapture is how many bytes of each output stream are kept in
+// memory when Options.MaxCapture is zero.
+const DefaultMaxCapture = 16 << 20
+
 type Result struct {
 	Label    string
 	Command  string
 	ExitCode int
+	// Output interleaves stdout and stderr in the order they were written.
 	Output   []byte
+	Stdout   []byte
+	Stderr   []byte
 	Duration time.Duration
+	TimedOut bool
+	Timeout  time.Duration
+	// OutputGap, StdoutGap and StderrGap mark the lines left out of each
+	// view when it outgrew Options.MaxCapture.
+	OutputGap Gap
+	StdoutGap Gap
+	StderrGap Gap
+	// Spill names a temporary file holding the complete interleaved output
+	// when Output is incomplete. Close removes it.
+	Spill string
+	// Attempt is the 1-based attempt that produced this result, out of at
+	// most Attempts.
+	Attempt  int
+	Attempts int
+	// FirstFailure is the first failed attempt if the command was retried.
+	FirstFailure *Result
+}
+
+// Close removes the spill files, if any.
+func (r *Result) Close() error {
+	var err error
+	if r.FirstFailure != nil {
+		err = r.FirstFailure.Close()
+	}
+	if r.Spill == "" {
+		return err
+	}
+	err = errors.Join(err, os.Remove(r.Spill))
+	r.Spill = ""
+	return err
 }
 
 type Options struct {
 	Command string
 	Label   string
+	// Shell runs Command as "<Shell> -c Command"; empty means sh. With
+	// ShellNone, Command is split into words and run without a shell.
+	Shell string
+	// Dir is the working directory; empty means the current one.
+	Dir string
+	// Env holds KEY=VALUE pairs added to the inherited environment. Later
+	// entries win over earlier ones.
+	Env         []string
+	Timeout     time.Duration
+	GracePeriod time.Duration
+	// OnLine, if set, is called with each line of output as the command
+	// writes it. Calls are serialized; line is only valid during the call.
+	OnLine func(line []byte)
+	// MaxCapture caps the bytes of each stream kept in memory: the head
+	// and tail are kept and the rest spills to disk. Zero means
+	// DefaultMaxCapture; negative means no limit.
+	MaxCapture int
+	// Retries is how many times a failed command is run again before its
+	// failure is reported, waiting RetryDelay before each retry.
+	Retries    int
+	RetryDelay time.Duration
+	// RetryOn, if set, is a regex that limits retries to failures whose
+	// output matches it.
+	RetryOn string
 }
 
+// Run runs the command, retrying it as set by opts.Retries. The result is
+// that of the last attempt.
 func Run(ctx context.Context, opts Options) (*Result, error) {
+	attempts := max(opts.Retries, 0) + 1
+	var first *Result
+	for attempt := 1; ; attempt++ {
+		r, err := runOnce(ctx, opts)
+		if err != nil {
+			if first != nil {
+				first.Close()
+			}
+			return nil, err
+		}
+		r.Attempt, r.Attempts = attempt, attempts
+		if r.ExitCode == 0 || attempt == attempts || ctx.Err() != nil ||
+			!retryable(r, opts.RetryOn) || !sleep(ctx, opts.RetryDelay) {
+			r.FirstFailure = first
+			return r, nil
+		}
+		if first == nil {
+			first = r
+		} else {
+			r.Close()
+		}
+	}
+}
+
+// retryable reports whether the failure r matches pattern; any failure
+// does if pattern is empty.
+func retryable(r *Result, pattern string) bool {
+	if pattern == "" {
+		return true
+	}
+	if r.Spill != "" {
+		if file, err := os.Open(r.Spill); err == nil {
+			defer file.Close()
+			if m, err := filter.MatchReader(file, pattern); err == nil {
+				return m.Count > 0
+			}
+		}
+	}
+	return filter.MatchLines(filter.StripANSI(r.Output), pattern).Count > 0
+}
+
+// sleep waits for d, reporting false if ctx is cancelled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func runOnce(ctx context.Context, opts Options) (*Result, error) {
 	label := opts.Label
 	if label == "" {
-		label = deriveLabel(opts.Command)
+		label = DeriveLabel(opts.Command)
+	}
+
+	runCtx := ctx
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	grace := opts.GracePeriod
+	if grace <= 0 {
+		grace = DefaultGracePeriod
 	}
 
 	start := time.Now()
-	cmd := exec.CommandContext(ctx, "sh", "-c", opts.Command)
-	output, err := cmd.CombinedOutput()
+	cmd, err := newCmd(runCtx, opts)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Dir = opts.Dir
+	if len(opts.Env) > 0 {
+		cmd.Env = append(os.Environ(), opts.Env...)
+	}
+	// Only a command that can be cancelled needs its own process group
+	// and a bound on waiting for output after it exits.
+	if opts.Timeout > 0 || ctx.Done() != nil {
+		setProcessGroup(cmd, grace)
+	}
+	maxCapture := opts.MaxCapture
+	if maxCapture == 0 {
+		maxCapture = DefaultMaxCapture
+	}
+	out := newCapture(maxCapture, opts.OnLine)
+	cmd.Stdout = out.writer(&out.stdout)
+	cmd.Stderr = out.writer(&out.stderr)
+	err = cmd.Run()
+	var notFound *exec.Error
+	if errors.As(err, &notFound) {
+		// Report a missing program the way sh does
+		fmt.Fprintln(cmd.Stderr, err)
+	}
 	duration := time.Since(start)
+	spill := out.finish()
+
+	timedOut := opts.Timeout > 0 && ctx.Err() == nil &&
+		errors.Is(runCtx.Err(), context.DeadlineExceeded)
 
 	exitCode := 0
 	if err != nil {
 		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
+		switch {
+		case errors.As(err, &exitErr):
 			exitCode = exitErr.ExitCode()
-		} else {
+		case notFound != nil:
+			exitCode = NotFoundExitCode
+		case errors.Is(err, exec.ErrWaitDelay):
+			// The command exited but a background child kept the output
+			// open; its exit status is what counts.
+			exitCode = cmd.ProcessState.ExitCode()
+		case !timedOut:
+			if spill != "" {
+				os.Remove(spill)
+			}
 			return nil, err
 		}
 	}
+	if timedOut {
+		exitCode = TimeoutExitCode
+	}
 
 	return &Result{
-		Label:    label,
-		Command:  opts.Command,
-		ExitCode: exitCode,
-		Output:   output,
-		Duration: duration,
+		Label:     label,
+		Command:   opts.Command,
+		ExitCode:  exitCode,
+		Output:    out.combined.Bytes(),
+		Stdout:    out.stdout.Bytes(),
+		Stderr:    out.stderr.Bytes(),
+		Duration:  duration,
+		TimedOut:  timedOut,
+		Timeout:   opts.Timeout,
+		OutputGap: out.combined.Gap(),
+		StdoutGap: out.stdout.Gap(),
+		StderrGap: out.stderr.Gap(),
+		Spill:     spill,
 	}, nil
 }
 
-func deriveLabel(command string) string {
-	fields := strings.Fields(command)
+// newCmd returns the process that runs opts.Command.
+func newCmd(ctx context.Context, opts Options) (*exec.Cmd, error) {
+	switch opts.Shell {
+	case "":
+		return exec.CommandContext(ctx, "sh", "-c", opts.Command), nil
+	case ShellNone:
+		args, err := splitArgs(opts.Command)
+		if err != nil {
+			return nil, err
+		}
+		return exec.CommandContext(ctx, args[0], args[1:]...), nil
+	}
+	return exec.CommandContext(ctx, opts.Shell, "-c", opts.Command), nil
+}
+
+// DeriveLabel returns the summary label used for command when none is given:
+// the detected tool's label, or else the program name, with the subcommand
+// for tools such as cargo.
+func DeriveLabel(command string) string {
+	if tool, ok := DetectTool(command); ok {
+		return tool.Label
+	}
+	fields := commandFields(command)
 	if len(fields) == 0 {
 		return "unknown"
 	}
-	// Use the first token as the label
-	token := fields[0]
-	// Strip path prefix
-	if idx := strings.LastIndex(token, "/"); idx >= 0 {
-		token = token[idx+1:]
+	label := fields[0]
+	if !slices.Contains(subcommandTools, label) || len(fields) < 2 || strings.HasPrefix(fields[1], "-") {
+		return label
+	}
+	label += " " + fields[1]
+	// The script name is what matters in "npm run lint"
+	if fields[1] == "run" && len(fields) > 2 && !strings.HasPrefix(fields[2], "-") {
+		label += " " + fields[2]
 	}
-	return token
+	return label
 }
@@ -1,7 +1,13 @@
 package runner
 
 import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestRunSuccess(t *testing.T) {
@@ -17,6 +23,36 @@ func TestRunSuccess(t *testing.T) {
 	}
 }
 
+func TestRunSeparatesStreams(t *testing.T) {
+	r, err := Run(t.Context(), Options{Command: "echo out1; sleep 0.05; echo err1 >&2; sleep 0.05; echo out2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(r.Stdout) != "out1\nout2\n" {
+		t.Errorf("unexpected stdout: %q", r.Stdout)
+	}
+	if string(r.Stderr) != "err1\n" {
+		t.Errorf("unexpected stderr: %q", r.Stderr)
+	}
+	if string(r.Output) != "out1\nerr1\nout2\n" {
+		t.Errorf("unexpected interleaved output: %q", r.Output)
+	}
+}
+
+func TestRunOnLine(t *testing.T) {
+	var lines []string
+	_, err := Run(t.Context(), Options{
+		Command: "printf 'one\\ntwo\\n'; printf 'partial' >&2",
+		OnLine:  func(line []byte) { lines = append(lines, string(line)) },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(lines, "|"); got != "one|two|partial" {
+		t.Errorf("unexpected lines: %q", got)
+	}
+}
+
 func TestRunFailure(t *testing.T) {
 	r, err := Run(t.Context(), Options{Command: "false"})
 	if err != nil {
@@ -37,6 +73,108 @@ func TestRunExitCode(t *testing.T) {
 	}
 }
 
+func TestRunDirAndEnv(t *testing.T) {
+	dir := t.TempDir()
+	r, err := Run(t.Context(), Options{
+		Command: "pwd; echo $HUSH_A $HUSH_B",
+		Dir:     dir,
+		Env:     []string{"HUSH_A=1", "HUSH_B=2", "HUSH_A=3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := dir + "\n3 2\n"
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		want = resolved + "\n3 2\n"
+	}
+	if string(r.Output) != want {
+		t.Errorf("unexpected output: %q, want %q", r.Output, want)
+	}
+}
+
+func TestRunShellNone(t *testing.T) {
+	r, err := Run(t.Context(), Options{Command: `printf '%s|' '&&' "$HOME" a\ b`, Shell: ShellNone})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(r.Output) != "&&|$HOME|a b|" {
+		t.Errorf("expected arguments passed verbatim, got %q", r.Output)
+	}
+
+	if _, err := Run(t.Context(), Options{Command: "make && make test", Shell: ShellNone}); err == nil {
+		t.Error("expected an error for an operator without a shell")
+	}
+}
+
+func TestRunShellNoneNotFound(t *testing.T) {
+	r, err := Run(t.Context(), Options{Command: "hush-no-such-program --flag", Shell: ShellNone})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.ExitCode != NotFoundExitCode || !strings.Contains(string(r.Output), "hush-no-such-program") {
+		t.Errorf("expected exit %d with an error message, got %d: %q", NotFoundExitCode, r.ExitCode, r.Output)
+	}
+}
+
+func TestRunShell(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not installed")
+	}
+	r, err := Run(t.Context(), Options{Command: "echo ${BASH_VERSION:+bash}", Shell: "bash"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(r.Output) != "bash\n" {
+		t.Errorf("expected the command to run in bash, got %q", r.Output)
+	}
+}
+
+func TestRunRetries(t *testing.T) {
+	// Fails on the first attempt and passes on the second
+	marker := filepath.Join(t.TempDir(), "ran")
+	r, err := Run(t.Context(), Options{
+		Command: "if [ -e " + marker + " ]; then echo ok; else touch " + marker + "; echo boom; exit 3; fi",
+		Retries: 2,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.ExitCode != 0 || r.Attempt != 2 || r.Attempts != 3 {
+		t.Errorf("expected a pass on attempt 2/3, got exit %d on %d/%d", r.ExitCode, r.Attempt, r.Attempts)
+	}
+	if r.FirstFailure == nil || r.FirstFailure.ExitCode != 3 || string(r.FirstFailure.Output) != "boom\n" {
+		t.Errorf("unexpected first failure: %+v", r.FirstFailure)
+	}
+}
+
+func TestRunRetriesExhausted(t *testing.T) {
+	r, err := Run(t.Context(), Options{Command: "exit 1", Retries: 2, RetryDelay: time.Millisecond})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.ExitCode != 1 || r.Attempt != 3 {
+		t.Errorf("expected a failure on attempt 3, got exit %d on attempt %d", r.ExitCode, r.Attempt)
+	}
+}
+
+func TestRunRetryOn(t *testing.T) {
+	r, err := Run(t.Context(), Options{Command: "echo assertion failed; exit 1", Retries: 2, RetryOn: "ECONNRESET"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Attempt != 1 || r.FirstFailure != nil {
+		t.Errorf("expected no retry of a non-matching failure, got attempt %d", r.Attempt)
+	}
+
+	r, err = Run(t.Context(), Options{Command: "echo 'read: ECONNRESET'; exit 1", Retries: 2, RetryOn: "ECONNRESET"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Attempt != 3 {
+		t.Errorf("expected a matching failure to be retried, got attempt %d", r.Attempt)
+	}
+}
+
 func TestRunLabel(t *testing.T) {
 	r, err := Run(t.Context(), Options{Command: "echo hello"})
 	if err != nil {
@@ -66,11 +204,196 @@ func TestDeriveLabel(t *testing.T) {
 		{"/usr/bin/pytest -x", "pytest"},
 		{"make build", "make"},
 		{"", "unknown"},
+		{"uv run pytest -x", "pytest"},
+		{"poetry run pytest", "pytest"},
+		{"python -m pytest tests/", "pytest"},
+		{"npx jest --ci", "jest"},
+		{"npx -y eslint .", "eslint"},
+		{"go test ./...", "go test"},
+		{"go build ./...", "go build"},
+		{"cargo test", "cargo test"},
+		{"uv run mypy src", "mypy"},
+		{"cd services/api && make", "make"},
+		{"cd a && cd b; make lint", "make"},
+		{"FOO=1 BAR=x go test ./...", "go test"},
+		{"env CI=1 pytest", "pytest"},
+		{"env -u FOO pytest", "pytest"},
+		{"uv run --with foo pytest", "pytest"},
+		{"npx -p typescript tsc", "tsc"},
+		{"bash -c 'cd web && npx jest'", "jest"},
+		{"sh -ec \"ruff check .\"", "ruff"},
+		{"bundle exec rspec", "rspec"},
+		{"go run ./cmd/migrate up", "migrate"},
+		{"cargo clippy -- -D warnings", "cargo clippy"},
+		{"cargo --locked build", "cargo"},
+		{"npm run lint", "npm run lint"},
+		{"npm test", "npm test"},
+		{"yarn run lint", "yarn run lint"},
+		{"yarn install", "yarn install"},
+		{"yarn exec jest", "jest"},
+		{"make check | tee log", "make"},
+		{"cd web", "cd"},
+		{"n=$(cat /tmp/n); go test -count=$n ./...", "go test"},
+		{"echo \"$(date | cut -c1-3)\" && make", "echo"},
 	}
 	for _, tt := range tests {
-		got := deriveLabel(tt.command)
+		got := DeriveLabel(tt.command)
 		if got != tt.want {
-			t.Errorf("deriveLabel(%q) = %q, want %q", tt.command, got, tt.want)
+			t.Errorf("DeriveLabel(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	start := time.Now()
+	r, err := Run(t.Context(), Options{
+		Command:     "sleep 10",
+		Timeout:     100 * time.Millisecond,
+		GracePeriod: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !r.TimedOut {
+		t.Error("expected TimedOut to be set")
+	}
+	if r.ExitCode != TimeoutExitCode {
+		t.Errorf("expected exit code %d, got %d", TimeoutExitCode, r.ExitCode)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("expected prompt kill, took %s", elapsed)
+	}
+}
+
+func TestRunTimeoutKillsGrandchildren(t *testing.T) {
+	// The background sleep inherits the output pipe; without a group kill
+	// Run would block until it exits.
+	start := time.Now()
+	r, err := Run(t.Context(), Options{
+		Command:     "sleep 10 & sleep 10",
+		Timeout:     100 * time.Millisecond,
+		GracePeriod: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !r.TimedOut {
+		t.Error("expected TimedOut to be set")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("expected grandchildren to be killed, took %s", elapsed)
+	}
+}
+
+func TestRunBackgroundChildKeepsExitStatus(t *testing.T) {
+	// Without a timeout, Run waits for the background child's output
+	r, err := Run(context.Background(), Options{Command: "sleep 0.3 & echo bg"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.ExitCode != 0 || string(r.Output) != "bg\n" {
+		t.Errorf("expected a pass, got exit %d output %q", r.ExitCode, r.Output)
+	}
+
+	// With one, Run stops waiting after the grace period but still reports
+	// how the command itself exited.
+	r, err = Run(t.Context(), Options{
+		Command:     "sleep 10 & echo bg; exit 3",
+		Timeout:     time.Minute,
+		GracePeriod: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.ExitCode != 3 || r.TimedOut {
+		t.Errorf("expected exit 3, got %d (timed out: %v)", r.ExitCode, r.TimedOut)
+	}
+	r, err = Run(t.Context(), Options{
+		Command:     "sleep 10 & echo bg",
+		Timeout:     time.Minute,
+		GracePeriod: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.ExitCode != 0 {
+		t.Errorf("expected a pass, got exit %d", r.ExitCode)
+	}
+}
+
+func TestRunTimeoutEscalatesToKill(t *testing.T) {
+	start := time.Now()
+	r, err := Run(t.Context(), Options{
+		Command:     "trap '' TERM; sleep 10",
+		Timeout:     100 * time.Millisecond,
+		GracePeriod: 200 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !r.TimedOut {
+		t.Error("expected TimedOut to be set")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("expected SIGKILL after grace period, took %s", elapsed)
+	}
+}
+
+func TestRunWithinTimeout(t *testing.T) {
+	r, err := Run(t.Context(), Options{Command: "echo hello", Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.TimedOut || r.ExitCode != 0 {
+		t.Errorf("expected clean exit, got exit %d timedOut=%v", r.ExitCode, r.TimedOut)
+	}
+}
+
+func TestAddWrappers(t *testing.T) {
+	saved := wrappers
+	t.Cleanup(func() { wrappers = saved })
+
+	AddWrappers("dotenv --", "with-secrets")
+	for command, want := range map[string]string{
+		"dotenv -- pytest -x":    "pytest",
+		"with-secrets make test": "make",
+		"dotenv pytest":          "dotenv",
+	} {
+		if got := DeriveLabel(command); got != want {
+			t.Errorf("DeriveLabel(%q) = %q, want %q", command, got, want)
+		}
+	}
+}
+
+func TestShellWords(t *testing.T) {
+	got := shellWords(`a "b c" 'd&&e' f\ g&&h||i|j;k`)
+	want := []string{"a", "b c", "d&&e", "f g", "&&", "h", "||", "i", "|", "j", ";", "k"}
+	if !slices.Equal(got, want) {
+		t.Errorf("shellWords = %q, want %q", got, want)
+	}
+}
+
+func TestDetectTool(t *testing.T) {
+	tests := []struct {
+		command    string
+		wantParser string
+		wantOK     bool
+	}{
+		{"pytest -x", "pytest", true},
+		{"uv run pytest", "pytest", true},
+		{"go test -race ./...", "gotest", true},
+		{"npx jest", "jest", true},
+		{"pnpm exec tsc --noEmit", "tsc", true},
+		{"cargo test --all", "cargo", true},
+		{"ruff check .", "", true},
+		{"node_modules/.bin/eslint src", "eslint", true},
+		{"make test", "", false},
+		{"go vet ./...", "", false},
+	}
+	for _, tt := range tests {
+		tool, ok := DetectTool(tt.command)
+		if ok != tt.wantOK || tool.Parser != tt.wantParser {
+			t.Errorf("DetectTool(%q) = %+v, %v; want parser %q, %v", tt.command, tool, ok, tt.wantParser, tt.wantOK)
 		}
 	}
 }
@@ -0,0 +1,307 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Tool describes a recognised command-line tool and the hush settings
+// that suit it.
+type Tool struct {
+	Label string
+	// Parser names the filter extractor for the tool's failure output.
+	Parser string
+	// WarnPattern matches the tool's warning lines.
+	WarnPattern string
+}
+
+// wrappers are command prefixes that run another program; they are skipped
+// when identifying the tool.
+var wrappers = [][]string{
+	{"env"},
+	{"uv", "run"},
+	{"poetry", "run"},
+	{"pipenv", "run"},
+	{"pnpm", "exec"},
+	{"pnpm", "dlx"},
+	{"npx"},
+	{"bunx"},
+	{"yarn", "exec"},
+	{"yarn", "dlx"},
+	{"python", "-m"},
+	{"python3", "-m"},
+	{"bundle", "exec"},
+	{"go", "run"},
+}
+
+// wrapperValueFlags are wrapper options whose value is the next word, as in
+// "env -u FOO" or "uv run --with foo".
+var wrapperValueFlags = []string{
+	"-u", "--unset", "-C", "--chdir",
+	"--with", "--with-editable", "--with-requirements", "--from",
+	"--python", "--project", "--directory", "--package", "--extra", "--group", "--env-file",
+	"-p", "--packages",
+}
+
+// AddWrappers registers extra wrapper prefixes such as "dotenv --". It is
+// meant to be called at startup, before any command runs.
+func AddWrappers(prefixes ...string) {
+	for _, p := range prefixes {
+		if fields := strings.Fields(p); len(fields) > 0 {
+			wrappers = append(wrappers, fields)
+		}
+	}
+}
+
+// subcommandTools are programs whose label includes the subcommand, as in
+// "cargo clippy" or "npm run lint".
+var subcommandTools = []string{"go", "cargo", "npm", "pnpm", "yarn", "bun", "dotnet"}
+
+// shells are programs whose -c argument is the command to look at.
+var shells = []string{"sh", "bash", "zsh", "dash"}
+
+var knownTools = []struct {
+	cmd  []string
+	tool Tool
+}{
+	{[]string{"pytest"}, Tool{Label: "pytest", Parser: "pytest", WarnPattern: `\w+Warning: `}},
+	{[]string{"py.test"}, Tool{Label: "pytest", Parser: "pytest", WarnPattern: `\w+Warning: `}},
+	{[]string{"go", "test"}, Tool{Label: "go test", Parser: "gotest"}},
+	{[]string{"jest"}, Tool{Label: "jest", Parser: "jest"}},
+	{[]string{"cargo", "test"}, Tool{Label: "cargo test", Parser: "cargo", WarnPattern: `^warning: `}},
+	{[]string{"tsc"}, Tool{Label: "tsc", Parser: "tsc"}},
+	{[]string{"ruff"}, Tool{Label: "ruff"}},
+	{[]string{"eslint"}, Tool{Label: "eslint", Parser: "eslint", WarnPattern: `^\s+\d+:\d+\s+warning\s`}},
+}
+
+// DetectTool identifies the tool command runs, looking through wrappers
+// such as "uv run" and "npx".
+func DetectTool(command string) (Tool, bool) {
+	fields := commandFields(command)
+	for _, known := range knownTools {
+		if len(fields) >= len(known.cmd) && slices.Equal(fields[:len(known.cmd)], known.cmd) {
+			return known.tool, true
+		}
+	}
+	return Tool{}, false
+}
+
+// commandFields splits command into words with wrapper prefixes removed
+// and the program reduced to its base name. Only the first command after
+// any "cd dir &&" is considered, and "bash -c '…'" is looked through.
+func commandFields(command string) []string {
+	fields := mainCommand(shellWords(command))
+	for stripped := true; stripped && len(fields) > 0; {
+		stripped = false
+		// Skip variable assignments, e.g. "CI=1 go test"
+		for len(fields) > 1 && isAssignment(fields[0]) {
+			fields = fields[1:]
+		}
+		fields[0] = filepath.Base(fields[0])
+		if slices.Contains(shells, fields[0]) && len(fields) > 2 && isShellCommandFlag(fields[1]) {
+			return commandFields(fields[2])
+		}
+		for _, w := range wrappers {
+			if len(fields) > len(w) && slices.Equal(fields[:len(w)], w) {
+				fields = fields[len(w):]
+				// Skip the wrapper's own flags, e.g. "npx -y jest", and the
+				// values of those that take one, e.g. "uv run --with foo"
+				for len(fields) > 1 && strings.HasPrefix(fields[0], "-") {
+					if len(fields) > 2 && slices.Contains(wrapperValueFlags, fields[0]) {
+						fields = fields[1:]
+					}
+					fields = fields[1:]
+				}
+				stripped = true
+				break
+			}
+		}
+	}
+	return fields
+}
+
+// shellWords splits command into words as a POSIX shell would, honouring
+// quotes and backslashes. The unquoted operators &&, ||, | and ; are words
+// of their own; a newline counts as ;.
+func shellWords(command string) []string {
+	var words []string
+	lexShell(command, func(word string, _ bool) {
+		words = append(words, word)
+	})
+	return words
+}
+
+// splitArgs splits command into the words of a single command, as run
+// without a shell.
+func splitArgs(command string) ([]string, error) {
+	var args []string
+	var op string
+	lexShell(command, func(word string, isOp bool) {
+		if isOp && op == "" {
+			op = word
+		}
+		args = append(args, word)
+	})
+	if op != "" {
+		return nil, fmt.Errorf("%q needs a shell to run %s", command, op)
+	}
+	if len(args) == 0 {
+		return nil, errors.New("empty command")
+	}
+	return args, nil
+}
+
+// lexShell calls emit with each word of command and with each operator,
+// flagged by op.
+func lexShell(command string, emit func(word string, op bool)) {
+	var word strings.Builder
+	inWord := false
+	flush := func() {
+		if inWord {
+			emit(word.String(), false)
+			word.Reset()
+			inWord = false
+		}
+	}
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == '\'':
+			inWord = true
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				end = len(command) - i - 1
+			}
+			word.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			inWord = true
+			for i++; i < len(command) && command[i] != '"'; i++ {
+				if command[i] == '\\' && i+1 < len(command) && strings.IndexByte("\"\\$`", command[i+1]) >= 0 {
+					i++
+				}
+				word.WriteByte(command[i])
+			}
+		case c == '\\' && i+1 < len(command):
+			inWord = true
+			i++
+			word.WriteByte(command[i])
+		case c == '$' && i+1 < len(command) && command[i+1] == '(':
+			// Keep a command substitution in its word, operators and all
+			inWord = true
+			end := substitutionEnd(command, i+2)
+			word.WriteString(command[i:end])
+			i = end - 1
+		case c == ' ' || c == '\t':
+			flush()
+		case c == ';' || c == '\n' || c == '|':
+			flush()
+			op := ";"
+			if c == '|' {
+				op = "|"
+				if i+1 < len(command) && command[i+1] == '|' {
+					op = "||"
+					i++
+				}
+			}
+			emit(op, true)
+		case c == '&' && i+1 < len(command) && command[i+1] == '&':
+			flush()
+			emit("&&", true)
+			i++
+		default:
+			inWord = true
+			word.WriteByte(c)
+		}
+	}
+	flush()
+}
+
+// substitutionEnd returns the index just past the ")" that closes the
+// "$(" ending at start, or len(command) if it is never closed.
+func substitutionEnd(command string, start int) int {
+	depth := 1
+	for i := start; i < len(command); i++ {
+		switch command[i] {
+		case '\\':
+			i++
+		case '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return len(command)
+			}
+			i += end + 1
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(command)
+}
+
+// mainCommand returns the words of the first command in words that follows
+// the last "cd dir" or bare variable assignment in a list of commands.
+func mainCommand(words []string) []string {
+	var commands [][]string
+	start := 0
+	for i, w := range words {
+		switch w {
+		case "&&", "||", "|", ";":
+			commands = append(commands, words[start:i])
+			start = i + 1
+		}
+	}
+	commands = append(commands, words[start:])
+
+	main := 0
+	for i, cmd := range commands {
+		if isSetup(cmd) && i+1 < len(commands) && len(commands[i+1]) > 0 {
+			main = i + 1
+		}
+	}
+	return commands[main]
+}
+
+// isSetup reports whether cmd only prepares for the command after it: a
+// change of directory or a bare variable assignment such as "n=$(cat n)".
+func isSetup(cmd []string) bool {
+	if len(cmd) == 0 {
+		return false
+	}
+	if cmd[0] == "cd" || cmd[0] == "pushd" {
+		return true
+	}
+	for _, w := range cmd {
+		if !isAssignment(w) {
+			return false
+		}
+	}
+	return true
+}
+
+// isAssignment reports whether word is a shell variable assignment.
+func isAssignment(word string) bool {
+	name, _, ok := strings.Cut(word, "=")
+	if !ok || name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isShellCommandFlag reports whether a shell flag such as -c or -ec takes
+// the command to run.
+func isShellCommandFlag(flag string) bool {
+	return strings.HasPrefix(flag, "-") && !strings.HasPrefix(flag, "--") && strings.HasSuffix(flag, "c")
+}
@@ -0,0 +1,254 @@
+// Package scaffold proposes checks for a project from the build files it
+// finds, and renders them as a .hush.yaml.
+package scaffold
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/alfranz/hush/internal/config"
+	"github.com/alfranz/hush/internal/runner"
+	"go.yaml.in/yaml/v3"
+)
+
+// Suggestion is a check proposed for a project.
+type Suggestion struct {
+	Name  string
+	Check config.Check
+	// Source is the file the check was derived from.
+	Source string
+}
+
+// defaultTail is the tail given to checks whose tool hush has no parser
+// for, so that a failing build shows its last lines only.
+const defaultTail = 50
+
+// targets are the script, target and recipe names worth a check, in the
+// order the checks are listed.
+var targets = []string{"lint", "check", "typecheck", "type-check", "test", "build"}
+
+// Detect looks for pyproject.toml, package.json, go.mod, Cargo.toml, a
+// Makefile and a justfile in dir and proposes checks for the tools they
+// use. A check whose name is taken is prefixed with its tool, as in
+// make-test.
+func Detect(dir string) ([]Suggestion, error) {
+	var all []Suggestion
+	for _, detect := range []func(string) ([]Suggestion, error){python, node, golang, rust, makefile, justfile} {
+		found, err := detect(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range found {
+			if slices.ContainsFunc(all, func(t Suggestion) bool { return t.Name == s.Name }) {
+				s.Name = strings.Fields(s.Check.Cmd)[0] + "-" + s.Name
+			}
+			all = append(all, s)
+		}
+	}
+	return all, nil
+}
+
+// Render returns suggestions as a .hush.yaml document.
+func Render(suggestions []Suggestion) ([]byte, error) {
+	checks := &yaml.Node{Kind: yaml.MappingNode}
+	for _, s := range suggestions {
+		var value yaml.Node
+		if err := value.Encode(s.Check); err != nil {
+			return nil, err
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: s.Name, HeadComment: "from " + s.Source}
+		checks.Content = append(checks.Content, key, &value)
+	}
+	doc := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "checks"}, checks,
+	}}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// suggest proposes running command as the check name, with the parser and
+// warn pattern of tool, the tool command runs if it is empty.
+func suggest(name, command, source, tool string) Suggestion {
+	if tool == "" {
+		tool = command
+	}
+	check := config.Check{Cmd: command}
+	if t, ok := runner.DetectTool(tool); ok {
+		check.Parser = t.Parser
+		check.WarnPattern = t.WarnPattern
+	}
+	if check.Parser == "" {
+		check.Tail = defaultTail
+	}
+	return Suggestion{Name: name, Check: check, Source: source}
+}
+
+// readFile returns the content of name in dir, or nil if it does not exist.
+func readFile(dir, name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// exists reports whether any of names exists in dir.
+func exists(dir string, names ...string) bool {
+	return firstExisting(dir, names...) != ""
+}
+
+// firstExisting returns the first of names that exists in dir, or "".
+func firstExisting(dir string, names ...string) string {
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+func python(dir string) ([]Suggestion, error) {
+	data, err := readFile(dir, "pyproject.toml")
+	if data == nil {
+		return nil, err
+	}
+	run := ""
+	switch {
+	case exists(dir, "uv.lock"):
+		run = "uv run "
+	case exists(dir, "poetry.lock"):
+		run = "poetry run "
+	}
+	// Tools show up as dependencies or [tool.*] tables
+	project := string(data)
+	var found []Suggestion
+	if strings.Contains(project, "ruff") {
+		found = append(found, suggest("lint", run+"ruff check .", "pyproject.toml", ""))
+	}
+	if strings.Contains(project, "mypy") {
+		found = append(found, suggest("typecheck", run+"mypy .", "pyproject.toml", ""))
+	}
+	if strings.Contains(project, "pytest") {
+		found = append(found, suggest("test", run+"pytest", "pyproject.toml", ""))
+	}
+	return found, nil
+}
+
+func node(dir string) ([]Suggestion, error) {
+	data, err := readFile(dir, "package.json")
+	if data == nil {
+		return nil, err
+	}
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+	pm := "npm"
+	switch {
+	case exists(dir, "pnpm-lock.yaml"):
+		pm = "pnpm"
+	case exists(dir, "yarn.lock"):
+		pm = "yarn"
+	case exists(dir, "bun.lock", "bun.lockb"):
+		pm = "bun"
+	}
+	var found []Suggestion
+	for _, name := range targets {
+		if script, ok := pkg.Scripts[name]; ok {
+			// The script tells which tool runs, and so which parser fits
+			found = append(found, suggest(name, pm+" run "+name, "package.json", script))
+		}
+	}
+	return found, nil
+}
+
+func golang(dir string) ([]Suggestion, error) {
+	if !exists(dir, "go.mod") {
+		return nil, nil
+	}
+	var found []Suggestion
+	if lintConfig := firstExisting(dir, ".golangci.yml", ".golangci.yaml", ".golangci.toml", ".golangci.json"); lintConfig != "" {
+		found = append(found, suggest("lint", "golangci-lint run ./...", lintConfig, ""))
+	}
+	return append(found,
+		suggest("vet", "go vet ./...", "go.mod", ""),
+		suggest("test", "go test ./...", "go.mod", ""),
+	), nil
+}
+
+func rust(dir string) ([]Suggestion, error) {
+	if !exists(dir, "Cargo.toml") {
+		return nil, nil
+	}
+	lint := suggest("lint", "cargo clippy --all-targets", "Cargo.toml", "")
+	lint.Check.WarnPattern = `^warning: `
+	return []Suggestion{lint, suggest("test", "cargo test", "Cargo.toml", "")}, nil
+}
+
+var (
+	makeTarget = regexp.MustCompile(`^([A-Za-z0-9_.-]+)\s*:([^=]|$)`)
+	justRecipe = regexp.MustCompile(`^@?([A-Za-z0-9_-]+)(\s+[^:]*)?:([^=]|$)`)
+)
+
+func makefile(dir string) ([]Suggestion, error) {
+	for _, name := range []string{"GNUmakefile", "Makefile", "makefile"} {
+		data, err := readFile(dir, name)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil {
+			return fromTargets(data, makeTarget, "make", name), nil
+		}
+	}
+	return nil, nil
+}
+
+func justfile(dir string) ([]Suggestion, error) {
+	for _, name := range []string{"justfile", "Justfile", ".justfile"} {
+		data, err := readFile(dir, name)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil {
+			return fromTargets(data, justRecipe, "just", name), nil
+		}
+	}
+	return nil, nil
+}
+
+// fromTargets proposes a check per known target defined in data, found by
+// pattern, run as "<program> <target>".
+func fromTargets(data []byte, pattern *regexp.Regexp, program, source string) []Suggestion {
+	defined := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if m := pattern.FindStringSubmatch(scanner.Text()); m != nil {
+			defined[m[1]] = true
+		}
+	}
+	var found []Suggestion
+	for _, name := range targets {
+		if defined[name] {
+			found = append(found, suggest(name, program+" "+name, source, ""))
+		}
+	}
+	return found
+}
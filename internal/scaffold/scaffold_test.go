@@ -0,0 +1,125 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alfranz/hush/internal/config"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDetect(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"pyproject.toml": "[project]\nname = \"app\"\n\n[dependency-groups]\ndev = [\"pytest>=8\", \"ruff\"]\n",
+		"uv.lock":        "",
+		"package.json":   `{"scripts": {"build": "vite build", "test": "jest --ci", "lint": "eslint src", "dev": "vite"}}`,
+		"pnpm-lock.yaml": "",
+		"Makefile":       ".PHONY: test\nVERSION := 1.0\ntest: build\n\tgo test ./...\nrelease:\n\tgoreleaser\n",
+		"justfile":       "set shell := [\"bash\", \"-c\"]\n\ncheck *args:\n  cargo check {{args}}\n",
+	})
+	got, err := Detect(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Suggestion{
+		{"lint", config.Check{Cmd: "uv run ruff check .", Tail: defaultTail}, "pyproject.toml"},
+		{"test", config.Check{Cmd: "uv run pytest", Parser: "pytest", WarnPattern: `\w+Warning: `}, "pyproject.toml"},
+		{"pnpm-lint", config.Check{Cmd: "pnpm run lint", Parser: "eslint", WarnPattern: `^\s+\d+:\d+\s+warning\s`}, "package.json"},
+		{"pnpm-test", config.Check{Cmd: "pnpm run test", Parser: "jest"}, "package.json"},
+		{"build", config.Check{Cmd: "pnpm run build", Tail: defaultTail}, "package.json"},
+		{"make-test", config.Check{Cmd: "make test", Tail: defaultTail}, "Makefile"},
+		{"check", config.Check{Cmd: "just check", Tail: defaultTail}, "justfile"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d suggestions, got %d: %+v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i].Name != want[i].Name || got[i].Source != want[i].Source || got[i].Check.Cmd != want[i].Check.Cmd ||
+			got[i].Check.Parser != want[i].Check.Parser || got[i].Check.WarnPattern != want[i].Check.WarnPattern || got[i].Check.Tail != want[i].Check.Tail {
+			t.Errorf("suggestion %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestDetectGoAndRust(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"go.mod":        "module example.com/app\n",
+		".golangci.yml": "linters: {}\n",
+		"Cargo.toml":    "[package]\nname = \"app\"\n",
+	})
+	got, err := Detect(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range got {
+		names = append(names, s.Name+": "+s.Check.Cmd)
+	}
+	want := []string{
+		"lint: golangci-lint run ./...",
+		"vet: go vet ./...",
+		"test: go test ./...",
+		"cargo-lint: cargo clippy --all-targets",
+		"cargo-test: cargo test",
+	}
+	if len(names) != len(want) {
+		t.Fatalf("expected %q, got %q", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("expected %q, got %q", want[i], names[i])
+		}
+	}
+}
+
+func TestDetectNothing(t *testing.T) {
+	got, err := Detect(t.TempDir())
+	if err != nil || len(got) != 0 {
+		t.Errorf("expected no suggestions, got %+v, %v", got, err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	suggestions := []Suggestion{
+		{"lint", config.Check{Cmd: "ruff check .", Tail: 50}, "pyproject.toml"},
+		{"test", config.Check{Cmd: "pytest", Parser: "pytest", WarnPattern: `\w+Warning: `}, "pyproject.toml"},
+	}
+	data, err := Render(suggestions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `checks:
+  # from pyproject.toml
+  lint:
+    cmd: ruff check .
+    tail: 50
+  # from pyproject.toml
+  test:
+    cmd: pytest
+    warn-pattern: '\w+Warning: '
+    parser: pytest
+`
+	if string(data) != want {
+		t.Errorf("unexpected config:\n%s", data)
+	}
+
+	cfg, err := config.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.CheckNames(); len(got) != 2 || got[0] != "lint" || cfg.Checks["test"].WarnPattern != `\w+Warning: ` {
+		t.Errorf("expected the config to parse back, got %+v", cfg.Checks)
+	}
+}
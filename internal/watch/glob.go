@@ -0,0 +1,48 @@
+package watch
+
+import (
+	"path"
+	"strings"
+)
+
+// Match reports whether the slash-separated path name matches pattern.
+// Pattern segments use path.Match syntax, and a ** segment matches any
+// number of directories. A pattern without a slash matches the base name
+// at any depth, as in .gitignore.
+func Match(pattern, name string) bool {
+	return matchAnchored(anchor(pattern), name)
+}
+
+// anchor rewrites pattern to match from the root: a pattern without a
+// slash matches at any depth, and a leading slash is dropped.
+func anchor(pattern string) string {
+	if strings.Contains(pattern, "/") {
+		return strings.TrimPrefix(pattern, "/")
+	}
+	return "**/" + pattern
+}
+
+func matchAnchored(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,27 @@
+package watch
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "internal/cli/root.go", true},
+		{"*.go", "main.py", false},
+		{"src/*.py", "src/app.py", true},
+		{"src/*.py", "src/pkg/app.py", false},
+		{"src/**/*.py", "src/app.py", true},
+		{"src/**/*.py", "src/pkg/sub/app.py", true},
+		{"/pyproject.toml", "pyproject.toml", true},
+		{"pyproject.toml", "services/api/pyproject.toml", true},
+		{"services/api/**", "services/api/go.mod", true},
+		{"services/api/**", "services/web/go.mod", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
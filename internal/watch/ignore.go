@@ -0,0 +1,109 @@
+package watch
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// Ignore holds .gitignore rules. Rules added later take precedence, as
+// do the rules of deeper .gitignore files when they are added in walk
+// order.
+type Ignore struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	// base is the directory of the .gitignore file, relative to the root.
+	base string
+	// pattern is anchored at base.
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+// AddFile adds the rules of the .gitignore file at file, which lives in
+// the directory base relative to the root. A missing file adds nothing.
+func (ig *Ignore) AddFile(file, base string) error {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		ig.Add(base, scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// Add adds a single .gitignore line for the directory base.
+func (ig *Ignore) Add(base, line string) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+	r := ignoreRule{base: base}
+	if rest, ok := strings.CutPrefix(line, "!"); ok {
+		r.negate = true
+		line = rest
+	}
+	line = strings.TrimPrefix(line, `\`)
+	if rest, ok := strings.CutSuffix(line, "/"); ok {
+		r.dirOnly = true
+		line = rest
+	}
+	if line == "" {
+		return
+	}
+	r.pattern = anchor(line)
+	ig.rules = append(ig.rules, r)
+}
+
+// Ignored reports whether the slash-separated path rel, relative to the
+// root, is ignored, either itself or through one of its directories.
+func (ig *Ignore) Ignored(rel string, dir bool) bool {
+	for i := range len(rel) {
+		if rel[i] == '/' && ig.match(rel[:i], true) {
+			return true
+		}
+	}
+	return ig.match(rel, dir)
+}
+
+func (ig *Ignore) match(rel string, dir bool) bool {
+	ignored := false
+	for _, r := range ig.rules {
+		if r.dirOnly && !dir {
+			continue
+		}
+		name := rel
+		if r.base != "" {
+			var ok bool
+			if name, ok = strings.CutPrefix(rel, r.base+"/"); !ok {
+				continue
+			}
+		}
+		if matchAnchored(r.pattern, name) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// alwaysIgnored are directories never watched: version control data and
+// hush's own run history.
+var alwaysIgnored = []string{".git", ".hush"}
+
+func isAlwaysIgnored(rel string) bool {
+	for _, dir := range alwaysIgnored {
+		if rel == dir || strings.HasPrefix(rel, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
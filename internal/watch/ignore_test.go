@@ -0,0 +1,40 @@
+package watch
+
+import "testing"
+
+func TestIgnored(t *testing.T) {
+	var ig Ignore
+	for _, line := range []string{
+		"# build output",
+		"node_modules/",
+		"/dist",
+		"*.log",
+		"!keep.log",
+		"",
+	} {
+		ig.Add("", line)
+	}
+	ig.Add("web", "*.tmp")
+
+	tests := []struct {
+		rel  string
+		dir  bool
+		want bool
+	}{
+		{"node_modules", true, true},
+		{"web/node_modules/react/index.js", false, true},
+		{"node_modules", false, false},
+		{"dist/app.js", false, true},
+		{"web/dist/app.js", false, false},
+		{"debug.log", false, true},
+		{"logs/keep.log", false, false},
+		{"web/cache.tmp", false, true},
+		{"cache.tmp", false, false},
+		{"src/main.go", false, false},
+	}
+	for _, tt := range tests {
+		if got := ig.Ignored(tt.rel, tt.dir); got != tt.want {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.rel, tt.dir, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// Package watch reports changes to the files of a project, skipping what
+// .gitignore excludes.
+package watch
+
+import (
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Change is a changed file.
+type Change struct {
+	// Path is relative to the root and slash-separated.
+	Path string
+	// Time is the file's modification time, or when its removal was seen.
+	Time time.Time
+}
+
+// Watcher watches a directory tree and reports changed files in batches,
+// once no further change has been seen for the debounce interval.
+type Watcher struct {
+	// C receives each batch of changes, sorted by path.
+	C <-chan []Change
+
+	root     string
+	debounce time.Duration
+	fs       *fsnotify.Watcher
+	ignore   Ignore
+	changes  chan []Change
+	done     chan struct{}
+}
+
+// New starts watching root and every directory below it that is not
+// ignored.
+func New(root string, debounce time.Duration) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		root:     root,
+		debounce: debounce,
+		fs:       fsw,
+		changes:  make(chan []Change),
+		done:     make(chan struct{}),
+	}
+	w.C = w.changes
+	if err := w.addTree(root); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	go w.loop()
+	return w, nil
+}
+
+// Close stops watching.
+func (w *Watcher) Close() error {
+	close(w.done)
+	return w.fs.Close()
+}
+
+// addTree watches dir and the directories below it.
+func (w *Watcher) addTree(dir string) error {
+	return walk(w.root, dir, &w.ignore, func(p, rel string, d fs.DirEntry) error {
+		if d.IsDir() {
+			return w.fs.Add(p)
+		}
+		return nil
+	})
+}
+
+// Files calls fn with every file below root that is not ignored, by its
+// path relative to root, slash-separated, in lexical order.
+func Files(root string, fn func(rel string) error) error {
+	var ig Ignore
+	return walk(root, root, &ig, func(p, rel string, d fs.DirEntry) error {
+		if d.IsDir() {
+			return nil
+		}
+		return fn(rel)
+	})
+}
+
+// walk calls fn with dir and every directory and file below it that is
+// not ignored, adding .gitignore files to ig on the way down.
+func walk(root, dir string, ig *Ignore, fn func(p, rel string, d fs.DirEntry) error) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Removed while walking
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		rel := relPath(root, p)
+		if rel != "" && (isAlwaysIgnored(rel) || ig.Ignored(rel, d.IsDir())) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if err := ig.AddFile(filepath.Join(p, ".gitignore"), rel); err != nil {
+				return err
+			}
+		}
+		return fn(p, rel, d)
+	})
+}
+
+func (w *Watcher) loop() {
+	pending := make(map[string]time.Time)
+	var batch []Change
+	var out chan []Change // set while a batch waits to be received
+	var quiet <-chan time.Time
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			if rel, ok := w.changed(event); ok {
+				pending[rel] = time.Now()
+				if info, err := os.Stat(event.Name); err == nil {
+					pending[rel] = info.ModTime()
+				}
+				quiet = time.After(w.debounce)
+			}
+		case _, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+		case <-quiet:
+			quiet = nil
+			for _, c := range batch {
+				if _, ok := pending[c.Path]; !ok {
+					pending[c.Path] = c.Time
+				}
+			}
+			batch = batch[:0]
+			for _, rel := range slices.Sorted(maps.Keys(pending)) {
+				batch = append(batch, Change{Path: rel, Time: pending[rel]})
+			}
+			clear(pending)
+			out = w.changes
+		case out <- batch:
+			batch, out = nil, nil
+		}
+	}
+}
+
+// changed returns the relative path an event reports a change to, unless
+// the path is ignored. New directories are watched as they appear.
+func (w *Watcher) changed(event fsnotify.Event) (string, bool) {
+	if event.Op == fsnotify.Chmod {
+		return "", false
+	}
+	rel := w.rel(event.Name)
+	if rel == "" || isAlwaysIgnored(rel) {
+		return "", false
+	}
+	dir := false
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			dir = true
+			if !w.ignore.Ignored(rel, true) {
+				w.addTree(event.Name)
+			}
+		}
+	}
+	if w.ignore.Ignored(rel, dir) {
+		return "", false
+	}
+	return rel, true
+}
+
+func (w *Watcher) rel(p string) string {
+	return relPath(w.root, p)
+}
+
+func relPath(root, p string) string {
+	rel, err := filepath.Rel(root, p)
+	if err != nil || rel == "." {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
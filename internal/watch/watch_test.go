@@ -0,0 +1,78 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWatcherBatchesChanges(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*.log\nbuild/\n"), 0644)
+	os.Mkdir(filepath.Join(root, "src"), 0755)
+	os.Mkdir(filepath.Join(root, "build"), 0755)
+
+	w, err := New(root, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	os.WriteFile(filepath.Join(root, "src", "a.go"), []byte("package a"), 0644)
+	os.WriteFile(filepath.Join(root, "debug.log"), []byte("ignored"), 0644)
+	os.WriteFile(filepath.Join(root, "build", "out"), []byte("ignored"), 0644)
+	os.MkdirAll(filepath.Join(root, ".hush", "runs"), 0755)
+	os.Mkdir(filepath.Join(root, "pkg"), 0755)
+	// Let the new directory be watched before writing into it
+	time.Sleep(20 * time.Millisecond)
+	os.WriteFile(filepath.Join(root, "pkg", "b.go"), []byte("package b"), 0644)
+
+	var got []string
+	deadline := time.After(5 * time.Second)
+	for !slices.Contains(got, "pkg/b.go") {
+		select {
+		case batch := <-w.C:
+			for _, c := range batch {
+				got = append(got, c.Path)
+			}
+		case <-deadline:
+			t.Fatalf("timed out waiting for changes, got %q", got)
+		}
+	}
+	for _, rel := range got {
+		switch rel {
+		case "src/a.go", "pkg", "pkg/b.go":
+		default:
+			t.Errorf("unexpected change reported: %q", rel)
+		}
+	}
+	if !slices.Contains(got, "src/a.go") {
+		t.Errorf("expected src/a.go in %q", got)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*.log\n"), 0644)
+	os.MkdirAll(filepath.Join(root, "src", "pkg"), 0755)
+	os.WriteFile(filepath.Join(root, "src", "pkg", ".gitignore"), []byte("gen/\n"), 0644)
+	os.MkdirAll(filepath.Join(root, "src", "pkg", "gen"), 0755)
+	os.MkdirAll(filepath.Join(root, ".git"), 0755)
+	for _, name := range []string{"main.go", "debug.log", "src/pkg/a.go", "src/pkg/gen/b.go", ".git/HEAD"} {
+		os.WriteFile(filepath.Join(root, name), nil, 0644)
+	}
+
+	var got []string
+	if err := Files(root, func(rel string) error {
+		got = append(got, rel)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{".gitignore", "main.go", "src/pkg/.gitignore", "src/pkg/a.go"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Files = %q, want %q", got, want)
+	}
+}